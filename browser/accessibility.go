@@ -0,0 +1,137 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"time"
+)
+
+type axNodeMetaData struct {
+	Id            string                 `json:"id"`
+	ParentId      string                 `json:"parent_id,omitempty"`
+	ChildIds      []string               `json:"child_ids,omitempty"`
+	Role          string                 `json:"role"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description,omitempty"`
+	Value         interface{}            `json:"value,omitempty"`
+	States        map[string]interface{} `json:"states,omitempty"`
+	Ignored       bool                   `json:"ignored,omitempty"`
+	BackendNodeId int64                  `json:"backend_node_id,omitempty"`
+	Xpath         string                 `json:"xpath,omitempty"`
+}
+
+// axValue
+/*
+decodes the raw json value of an accessibility value
+*/
+func axValue(v *accessibility.Value) interface{} {
+	if v == nil || len(v.Value) == 0 {
+		return nil
+	}
+
+	var val interface{}
+	if err := json.Unmarshal(v.Value, &val); err != nil {
+		return string(v.Value)
+	}
+
+	return val
+}
+
+// axString
+/*
+decodes an accessibility value that is expected to be a string
+*/
+func axString(v *accessibility.Value) string {
+	if str, ok := axValue(v).(string); ok {
+		return str
+	}
+
+	return ""
+}
+
+// parseAxTree
+/*
+converts the accessibility tree into the structures we save, the xpath map links each node back to its dom node
+*/
+func parseAxTree(
+	nodes []*accessibility.Node,
+	xpathMap map[cdp.BackendNodeID]string,
+	includeIgnored bool) []axNodeMetaData {
+
+	axSlice := make([]axNodeMetaData, 0, len(nodes))
+
+	for _, node := range nodes {
+		if node.Ignored && !includeIgnored {
+			continue
+		}
+
+		var childIds []string
+		for _, id := range node.ChildIDs {
+			childIds = append(childIds, string(id))
+		}
+
+		var states map[string]interface{}
+		for _, prop := range node.Properties {
+			if states == nil {
+				states = map[string]interface{}{}
+			}
+			states[prop.Name.String()] = axValue(prop.Value)
+		}
+
+		axSlice = append(axSlice, axNodeMetaData{
+			Id:            string(node.NodeID),
+			ParentId:      string(node.ParentID),
+			ChildIds:      childIds,
+			Role:          axString(node.Role),
+			Name:          axString(node.Name),
+			Description:   axString(node.Description),
+			Value:         axValue(node.Value),
+			States:        states,
+			Ignored:       node.Ignored,
+			BackendNodeId: node.BackendDOMNodeID.Int64(),
+			Xpath:         xpathMap[node.BackendDOMNodeID],
+		})
+	}
+
+	return axSlice
+}
+
+// axTreeAction
+/*
+collects the full accessibility tree of the page along with the xpath of each node's dom node
+*/
+func axTreeAction(includeIgnored bool, axSlice *[]axNodeMetaData) chromedp.Tasks {
+	return chromedp.Tasks{
+		accessibility.Enable(),
+		chromedp.ActionFunc(func(c context.Context) error {
+			nodes, err := accessibility.GetFullAXTree().Do(c)
+			if err != nil {
+				return err
+			}
+
+			var domNodes []*cdp.Node
+			err = chromedp.Nodes(
+				"html",
+				&domNodes,
+				chromedp.ByQuery,
+				chromedp.Populate(-1, true, chromedp.PopulateWait(1*time.Second)),
+			).Do(c)
+
+			if err != nil {
+				return err
+			}
+
+			xpathMap := map[cdp.BackendNodeID]string{}
+			for _, node := range flattenNode(domNodes) {
+				xpathMap[node.BackendNodeID] = node.FullXPath()
+			}
+
+			*axSlice = parseAxTree(nodes, xpathMap, includeIgnored)
+
+			return nil
+		}),
+	}
+}
@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"testing"
+)
+
+func TestParseAxTree(t *testing.T) {
+	nodes := []*accessibility.Node{
+		{
+			NodeID:           "1",
+			Role:             &accessibility.Value{Type: accessibility.ValueTypeRole, Value: []byte(`"button"`)},
+			Name:             &accessibility.Value{Type: accessibility.ValueTypeComputedString, Value: []byte(`"Submit"`)},
+			BackendDOMNodeID: cdp.BackendNodeID(10),
+			ChildIDs:         []accessibility.NodeID{"2"},
+			Properties: []*accessibility.Property{
+				{
+					Name:  accessibility.PropertyNameFocusable,
+					Value: &accessibility.Value{Type: accessibility.ValueTypeBooleanOrUndefined, Value: []byte(`true`)},
+				},
+			},
+		},
+		{
+			NodeID:   "2",
+			ParentID: "1",
+			Ignored:  true,
+		},
+	}
+
+	xpathMap := map[cdp.BackendNodeID]string{
+		cdp.BackendNodeID(10): "/html[1]/body[1]/button[1]",
+	}
+
+	axSlice := parseAxTree(nodes, xpathMap, false)
+
+	if len(axSlice) != 1 {
+		t.Fatalf("ignored node was not dropped, found %d nodes", len(axSlice))
+	}
+
+	node := axSlice[0]
+
+	if node.Role != "button" || node.Name != "Submit" {
+		t.Errorf("role or name decoded incorrectly: %s, %s", node.Role, node.Name)
+	}
+
+	if node.Xpath != "/html[1]/body[1]/button[1]" {
+		t.Error("xpath was not linked to the accessibility node")
+	}
+
+	if focusable, ok := node.States["focusable"].(bool); !ok || !focusable {
+		t.Error("focusable state was not recorded")
+	}
+
+	if len(parseAxTree(nodes, xpathMap, true)) != 2 {
+		t.Error("ignored node was dropped when it should be included")
+	}
+}
@@ -0,0 +1,598 @@
+package browser
+
+import (
+	"agent/helper"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type auditFinding struct {
+	RuleId    string `json:"rule_id"`
+	Criterion string `json:"wcag_criterion"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	Xpath     string `json:"xpath"`
+}
+
+type auditReport struct {
+	Rules    []string       `json:"rules"`
+	Findings []auditFinding `json:"findings"`
+}
+
+// AuditDocument
+/*
+the element nodes of a page in document order, along with a lookup of each node's computed styles
+*/
+type AuditDocument struct {
+	nodes  []*nodeWithStyles
+	styles map[*cdp.Node]map[string]string
+}
+
+// Nodes
+/*
+the element nodes of the page in the order they appear
+*/
+func (doc *AuditDocument) Nodes() []*cdp.Node {
+	nodes := make([]*cdp.Node, 0, len(doc.nodes))
+	for _, n := range doc.nodes {
+		nodes = append(nodes, n.node)
+	}
+
+	return nodes
+}
+
+// Styles
+/*
+the computed styles of a node keyed by property name
+*/
+func (doc *AuditDocument) Styles(n *cdp.Node) map[string]string {
+	return doc.styles[n]
+}
+
+type AuditViolation struct {
+	Node    *cdp.Node
+	Message string
+}
+
+type auditRule struct {
+	id        string
+	criterion string
+	severity  string
+	check     func(doc *AuditDocument) []AuditViolation
+}
+
+var (
+	auditRulesMu sync.RWMutex
+	auditRules   = []auditRule{
+		{"image-alt", "1.1.1", "critical", checkImageAlt},
+		{"color-contrast", "1.4.3", "serious", checkColorContrast},
+		{"link-name", "2.4.4", "serious", checkLinkName},
+		{"button-name", "4.1.2", "critical", checkButtonName},
+		{"label", "1.3.1", "critical", checkFormLabels},
+		{"heading-order", "1.3.1", "moderate", checkHeadingOrder},
+		{"html-lang", "3.1.1", "serious", checkHtmlLang},
+	}
+)
+
+func getAuditRules() []auditRule {
+	auditRulesMu.RLock()
+	defer auditRulesMu.RUnlock()
+
+	return append([]auditRule{}, auditRules...)
+}
+
+// RegisterAuditRule
+/*
+Adds a rule to audit_accessibility, it runs after the rules registered before it and is selected by its id like the
+built-in rules. severity is one of critical, serious, moderate or minor
+*/
+func RegisterAuditRule(id, criterion, severity string, check func(doc *AuditDocument) []AuditViolation) error {
+	if id == "" || check == nil {
+		return errors.New("an audit rule needs an id and a check")
+	}
+
+	if !helper.Contains[string]([]string{"critical", "serious", "moderate", "minor"}, severity) {
+		return fmt.Errorf("audit rule severity %s not supported", severity)
+	}
+
+	auditRulesMu.Lock()
+	defer auditRulesMu.Unlock()
+
+	for _, rule := range auditRules {
+		if rule.id == id {
+			return fmt.Errorf("audit rule %s is already registered", id)
+		}
+	}
+
+	auditRules = append(auditRules, auditRule{id, criterion, severity, check})
+
+	return nil
+}
+
+// AuditRuleIds
+/*
+returns the ids of the built-in accessibility rules followed by the rules added with RegisterAuditRule
+*/
+func AuditRuleIds() []string {
+	var ids []string
+	for _, rule := range getAuditRules() {
+		ids = append(ids, rule.id)
+	}
+
+	return ids
+}
+
+// newAuditDocument
+/*
+builds the document from collected nodes. Recursively collected nodes are breadth first so they are put back in the
+order of the page, which rules such as heading-order rely on
+*/
+func newAuditDocument(nodes []*nodeWithStyles) *AuditDocument {
+	doc := AuditDocument{
+		styles: map[*cdp.Node]map[string]string{},
+	}
+
+	collected := map[*cdp.Node]*nodeWithStyles{}
+	for _, n := range nodes {
+		collected[n.node] = n
+	}
+
+	var roots []*cdp.Node
+	for _, n := range nodes {
+		if _, ok := collected[n.node.Parent]; !ok {
+			roots = append(roots, n.node)
+		}
+	}
+
+	for _, node := range documentOrder(roots) {
+		n, ok := collected[node]
+		if !ok || node.NodeType != cdp.NodeTypeElement {
+			continue
+		}
+
+		// a root can sit inside another root when only some nodes were collected
+		if _, added := doc.styles[node]; added {
+			continue
+		}
+
+		styleMap := map[string]string{}
+		for _, prop := range n.cssStyles {
+			styleMap[prop.Name] = prop.Value
+		}
+
+		doc.nodes = append(doc.nodes, n)
+		doc.styles[node] = styleMap
+	}
+
+	return &doc
+}
+
+// runAudit
+/*
+runs the selected rules (all rules when none are selected) over the document
+*/
+func runAudit(doc *AuditDocument, ruleIds []string) auditReport {
+	report := auditReport{
+		Rules:    []string{},
+		Findings: []auditFinding{},
+	}
+
+	for _, rule := range getAuditRules() {
+		if len(ruleIds) > 0 && !helper.Contains[string](ruleIds, rule.id) {
+			continue
+		}
+
+		report.Rules = append(report.Rules, rule.id)
+
+		for _, violation := range rule.check(doc) {
+			report.Findings = append(report.Findings, auditFinding{
+				RuleId:    rule.id,
+				Criterion: rule.criterion,
+				Severity:  rule.severity,
+				Message:   violation.Message,
+				Xpath:     violation.Node.FullXPath(),
+			})
+		}
+	}
+
+	return report
+}
+
+// nodeText
+/*
+the text content of a node, ignoring scripts and styles
+*/
+func nodeText(n *cdp.Node) string {
+	if n.NodeType == cdp.NodeTypeText {
+		return n.NodeValue
+	}
+
+	if n.LocalName == "script" || n.LocalName == "style" {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, child := range n.Children {
+		sb.WriteString(nodeText(child))
+	}
+
+	return sb.String()
+}
+
+// hasDirectText
+/*
+whether the node itself holds visible text rather than only its descendants
+*/
+func hasDirectText(n *cdp.Node) bool {
+	for _, child := range n.Children {
+		if child.NodeType == cdp.NodeTypeText && strings.TrimSpace(child.NodeValue) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasAccessibleName
+/*
+whether the node has a name from its text, aria attributes, title, or the alt text of a child image
+*/
+func hasAccessibleName(n *cdp.Node) bool {
+	if strings.TrimSpace(nodeText(n)) != "" {
+		return true
+	}
+
+	for _, attr := range []string{"aria-label", "aria-labelledby", "title"} {
+		if strings.TrimSpace(n.AttributeValue(attr)) != "" {
+			return true
+		}
+	}
+
+	for _, child := range flattenNode(n.Children) {
+		if child.LocalName == "img" && strings.TrimSpace(child.AttributeValue("alt")) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isAriaHidden(n *cdp.Node) bool {
+	for p := n; p != nil; p = p.Parent {
+		if p.AttributeValue("aria-hidden") == "true" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkImageAlt(doc *AuditDocument) []AuditViolation {
+	var violations []AuditViolation
+
+	for _, n := range doc.nodes {
+		node := n.node
+		if node.LocalName != "img" || isAriaHidden(node) {
+			continue
+		}
+
+		role := node.AttributeValue("role")
+		if role == "presentation" || role == "none" {
+			continue
+		}
+
+		_, hasAlt := node.Attribute("alt")
+		if hasAlt || node.AttributeValue("aria-label") != "" || node.AttributeValue("aria-labelledby") != "" {
+			continue
+		}
+
+		violations = append(violations, AuditViolation{node, "image has no alt attribute"})
+	}
+
+	return violations
+}
+
+func checkLinkName(doc *AuditDocument) []AuditViolation {
+	var violations []AuditViolation
+
+	for _, n := range doc.nodes {
+		node := n.node
+		if node.LocalName != "a" || isAriaHidden(node) {
+			continue
+		}
+
+		if _, ok := node.Attribute("href"); !ok {
+			continue
+		}
+
+		if !hasAccessibleName(node) {
+			violations = append(violations, AuditViolation{node, "link has no discernible text"})
+		}
+	}
+
+	return violations
+}
+
+func checkButtonName(doc *AuditDocument) []AuditViolation {
+	var violations []AuditViolation
+
+	for _, n := range doc.nodes {
+		node := n.node
+		if isAriaHidden(node) {
+			continue
+		}
+
+		inputType := strings.ToLower(node.AttributeValue("type"))
+
+		switch {
+		case node.LocalName == "input" && (inputType == "button" || inputType == "submit" || inputType == "reset"):
+			// submit and reset inputs get a default name from the browser
+			if inputType == "button" && strings.TrimSpace(node.AttributeValue("value")) == "" && !hasAccessibleName(node) {
+				violations = append(violations, AuditViolation{node, "button has no discernible text"})
+			}
+		case node.LocalName == "button" || node.AttributeValue("role") == "button":
+			if !hasAccessibleName(node) {
+				violations = append(violations, AuditViolation{node, "button has no discernible text"})
+			}
+		}
+	}
+
+	return violations
+}
+
+func checkFormLabels(doc *AuditDocument) []AuditViolation {
+	var violations []AuditViolation
+
+	labelled := map[string]bool{}
+	for _, n := range doc.nodes {
+		if n.node.LocalName == "label" {
+			labelled[n.node.AttributeValue("for")] = true
+		}
+	}
+
+	unlabelledTypes := []string{"hidden", "button", "submit", "reset", "image"}
+
+	for _, n := range doc.nodes {
+		node := n.node
+		if node.LocalName != "input" && node.LocalName != "select" && node.LocalName != "textarea" {
+			continue
+		}
+
+		if node.LocalName == "input" && helper.Contains[string](unlabelledTypes, strings.ToLower(node.AttributeValue("type"))) {
+			continue
+		}
+
+		if isAriaHidden(node) {
+			continue
+		}
+
+		if id := node.AttributeValue("id"); id != "" && labelled[id] {
+			continue
+		}
+
+		if node.AttributeValue("aria-label") != "" ||
+			node.AttributeValue("aria-labelledby") != "" ||
+			node.AttributeValue("title") != "" {
+			continue
+		}
+
+		wrapped := false
+		for p := node.Parent; p != nil; p = p.Parent {
+			if p.LocalName == "label" {
+				wrapped = true
+				break
+			}
+		}
+
+		if !wrapped {
+			violations = append(violations, AuditViolation{node, "form field has no label"})
+		}
+	}
+
+	return violations
+}
+
+func checkHeadingOrder(doc *AuditDocument) []AuditViolation {
+	var violations []AuditViolation
+	previous := 0
+
+	for _, n := range doc.nodes {
+		name := n.node.LocalName
+		if len(name) != 2 || name[0] != 'h' || name[1] < '1' || name[1] > '6' {
+			continue
+		}
+
+		level := int(name[1] - '0')
+		if previous > 0 && level > previous+1 {
+			violations = append(violations, AuditViolation{
+				n.node,
+				fmt.Sprintf("heading level skips from h%d to h%d", previous, level),
+			})
+		}
+
+		previous = level
+	}
+
+	return violations
+}
+
+func checkHtmlLang(doc *AuditDocument) []AuditViolation {
+	var violations []AuditViolation
+
+	for _, n := range doc.nodes {
+		if n.node.LocalName == "html" && strings.TrimSpace(n.node.AttributeValue("lang")) == "" {
+			violations = append(violations, AuditViolation{n.node, "html element has no lang attribute"})
+		}
+	}
+
+	return violations
+}
+
+var rgbRegex = regexp.MustCompile(`rgba?\(\s*([\d.]+)[\s,]+([\d.]+)[\s,]+([\d.]+)(?:[\s,/]+([\d.]+%?))?\s*\)`)
+
+type rgba struct {
+	r, g, b, a float64
+}
+
+// parseColor
+/*
+parses a computed css color of the form rgb(r, g, b) or rgba(r, g, b, a)
+*/
+func parseColor(value string) (rgba, bool) {
+	match := rgbRegex.FindStringSubmatch(value)
+	if match == nil {
+		return rgba{}, false
+	}
+
+	color := rgba{a: 1}
+	color.r, _ = strconv.ParseFloat(match[1], 64)
+	color.g, _ = strconv.ParseFloat(match[2], 64)
+	color.b, _ = strconv.ParseFloat(match[3], 64)
+
+	if match[4] != "" {
+		if strings.HasSuffix(match[4], "%") {
+			alpha, _ := strconv.ParseFloat(strings.TrimSuffix(match[4], "%"), 64)
+			color.a = alpha / 100
+		} else {
+			color.a, _ = strconv.ParseFloat(match[4], 64)
+		}
+	}
+
+	return color, true
+}
+
+// blend
+/*
+composites a translucent color over an opaque background
+*/
+func blend(fg, bg rgba) rgba {
+	return rgba{
+		r: fg.r*fg.a + bg.r*(1-fg.a),
+		g: fg.g*fg.a + bg.g*(1-fg.a),
+		b: fg.b*fg.a + bg.b*(1-fg.a),
+		a: 1,
+	}
+}
+
+// relativeLuminance
+/*
+the WCAG relative luminance of an opaque color
+*/
+func relativeLuminance(c rgba) float64 {
+	channel := func(v float64) float64 {
+		v /= 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+
+	return 0.2126*channel(c.r) + 0.7152*channel(c.g) + 0.0722*channel(c.b)
+}
+
+// contrastRatio
+/*
+the WCAG contrast ratio between two opaque colors, ranging from 1 to 21
+*/
+func contrastRatio(c1, c2 rgba) float64 {
+	l1, l2 := relativeLuminance(c1), relativeLuminance(c2)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// backgroundColor
+/*
+finds the effective background of a node by compositing the backgrounds of its ancestors over a white canvas
+*/
+func (doc *AuditDocument) backgroundColor(n *cdp.Node) rgba {
+	var layers []rgba
+	for p := n; p != nil; p = p.Parent {
+		if color, ok := parseColor(doc.styles[p]["background-color"]); ok && color.a > 0 {
+			layers = append(layers, color)
+			if color.a >= 1 {
+				break
+			}
+		}
+	}
+
+	bg := rgba{255, 255, 255, 1}
+	for i := len(layers) - 1; i >= 0; i-- {
+		bg = blend(layers[i], bg)
+	}
+
+	return bg
+}
+
+func isLargeText(styles map[string]string) bool {
+	size, err := strconv.ParseFloat(strings.TrimSuffix(styles["font-size"], "px"), 64)
+	if err != nil {
+		return false
+	}
+
+	weight, _ := strconv.Atoi(styles["font-weight"])
+
+	// 18pt or 14pt bold, in css pixels
+	return size >= 24 || (size >= 18.66 && weight >= 700)
+}
+
+func checkColorContrast(doc *AuditDocument) []AuditViolation {
+	var violations []AuditViolation
+
+	for _, n := range doc.nodes {
+		node := n.node
+		styles := doc.styles[node]
+
+		if !hasDirectText(node) || isAriaHidden(node) {
+			continue
+		}
+
+		if styles["display"] == "none" || styles["visibility"] == "hidden" {
+			continue
+		}
+
+		fg, ok := parseColor(styles["color"])
+		if !ok {
+			continue
+		}
+
+		bg := doc.backgroundColor(node)
+		ratio := contrastRatio(blend(fg, bg), bg)
+
+		required := 4.5
+		if isLargeText(styles) {
+			required = 3
+		}
+
+		if ratio < required {
+			violations = append(violations, AuditViolation{
+				node,
+				fmt.Sprintf("contrast ratio %.2f:1 is below the required %.1f:1", ratio, required),
+			})
+		}
+	}
+
+	return violations
+}
+
+// documentOrder
+/*
+flattens the node tree depth first so nodes keep the order they appear in the page
+*/
+func documentOrder(nodeSlice []*cdp.Node) []*cdp.Node {
+	var ordered []*cdp.Node
+	for _, node := range nodeSlice {
+		ordered = append(ordered, node)
+		ordered = append(ordered, documentOrder(node.Children)...)
+	}
+
+	return ordered
+}
@@ -0,0 +1,216 @@
+package browser
+
+import (
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/css"
+	"math"
+	"testing"
+)
+
+var testNodeId = cdp.NodeID(0)
+
+// element builds an element node and links its children back to it
+func element(name string, attributes []string, children ...*cdp.Node) *cdp.Node {
+	testNodeId++
+	n := &cdp.Node{
+		NodeID:     testNodeId,
+		NodeType:   cdp.NodeTypeElement,
+		LocalName:  name,
+		Attributes: attributes,
+		Children:   children,
+	}
+
+	for _, child := range children {
+		child.Parent = n
+	}
+
+	return n
+}
+
+func text(value string) *cdp.Node {
+	testNodeId++
+	return &cdp.Node{
+		NodeID:    testNodeId,
+		NodeType:  cdp.NodeTypeText,
+		NodeValue: value,
+	}
+}
+
+func styled(root *cdp.Node, styles map[*cdp.Node][]*css.ComputedStyleProperty) []*nodeWithStyles {
+	var nodeSlice []*nodeWithStyles
+	for _, node := range documentOrder([]*cdp.Node{root}) {
+		nodeSlice = append(nodeSlice, &nodeWithStyles{cssStyles: styles[node], node: node})
+	}
+
+	return nodeSlice
+}
+
+func countFindings(report auditReport, ruleId string) int {
+	count := 0
+	for _, finding := range report.Findings {
+		if finding.RuleId == ruleId {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestContrastRatio(t *testing.T) {
+	black := rgba{0, 0, 0, 1}
+	white := rgba{255, 255, 255, 1}
+
+	if ratio := contrastRatio(black, white); math.Abs(ratio-21) > 0.01 {
+		t.Errorf("black on white should be 21:1, got %.2f", ratio)
+	}
+
+	if ratio := contrastRatio(white, white); ratio != 1 {
+		t.Errorf("white on white should be 1:1, got %.2f", ratio)
+	}
+
+	color, ok := parseColor("rgba(255, 0, 0, 0.5)")
+	if !ok || color.r != 255 || color.a != 0.5 {
+		t.Error("failed to parse rgba color")
+	}
+
+	if _, ok = parseColor("transparent"); ok {
+		t.Error("parsed a color that is not rgb")
+	}
+}
+
+func TestRunAudit(t *testing.T) {
+	lowContrast := element("p", nil, text("hard to read"))
+	highContrast := element("p", nil, text("easy to read"))
+
+	body := element("body", nil,
+		element("img", []string{"src", "a.png"}),
+		element("img", []string{"src", "b.png", "alt", ""}),
+		element("a", []string{"href", "/"}),
+		element("a", []string{"href", "/about"}, text("About")),
+		element("button", nil),
+		element("button", []string{"aria-label", "Close"}),
+		element("input", []string{"type", "text"}),
+		element("label", nil, text("Name"), element("input", []string{"type", "text"})),
+		element("input", []string{"type", "submit"}),
+		element("h1", nil, text("Title")),
+		element("h3", nil, text("Skipped")),
+		lowContrast,
+		highContrast,
+	)
+	root := element("html", nil, element("head", nil), body)
+
+	styles := map[*cdp.Node][]*css.ComputedStyleProperty{
+		body: {
+			{Name: "background-color", Value: "rgb(255, 255, 255)"},
+		},
+		lowContrast: {
+			{Name: "color", Value: "rgb(200, 200, 200)"},
+			{Name: "font-size", Value: "16px"},
+		},
+		highContrast: {
+			{Name: "color", Value: "rgb(0, 0, 0)"},
+			{Name: "font-size", Value: "16px"},
+		},
+	}
+
+	report := runAudit(newAuditDocument(styled(root, styles)), nil)
+
+	expected := map[string]int{
+		"image-alt":      1,
+		"link-name":      1,
+		"button-name":    1,
+		"label":          1,
+		"heading-order":  1,
+		"html-lang":      1,
+		"color-contrast": 1,
+	}
+
+	for ruleId, count := range expected {
+		if found := countFindings(report, ruleId); found != count {
+			t.Errorf("rule %s found %d violations, expected %d", ruleId, found, count)
+		}
+	}
+
+	report = runAudit(newAuditDocument(styled(root, styles)), []string{"html-lang"})
+
+	if len(report.Rules) != 1 || len(report.Findings) != 1 {
+		t.Error("audit did not limit itself to the selected rules")
+	}
+
+	if report.Findings[0].Criterion != "3.1.1" || report.Findings[0].Xpath == "" {
+		t.Error("finding is missing its criterion or xpath")
+	}
+}
+
+func TestAuditDocumentOrder(t *testing.T) {
+	root := element("html", []string{"lang", "en"}, element("body", nil,
+		element("div", nil, element("h1", nil, text("Title"))),
+		element("h2", nil, text("Section")),
+		element("div", nil, element("h3", nil, text("Subsection"))),
+	))
+
+	// collect_nodes gathers nodes breadth first, h2 h1 h3
+	var nodeSlice []*nodeWithStyles
+	for _, node := range flattenNode([]*cdp.Node{root}) {
+		nodeSlice = append(nodeSlice, &nodeWithStyles{node: node})
+	}
+
+	report := runAudit(newAuditDocument(nodeSlice), []string{"heading-order"})
+
+	if len(report.Findings) != 0 {
+		t.Errorf("headings were not audited in document order: %v", report.Findings)
+	}
+}
+
+func TestRegisterAuditRule(t *testing.T) {
+	// the registry is shared by every test in the package
+	rules := getAuditRules()
+	t.Cleanup(func() {
+		auditRulesMu.Lock()
+		defer auditRulesMu.Unlock()
+
+		auditRules = rules
+	})
+
+	check := func(doc *AuditDocument) []AuditViolation {
+		var violations []AuditViolation
+		for _, node := range doc.Nodes() {
+			if node.LocalName == "marquee" {
+				violations = append(violations, AuditViolation{node, "marquee is distracting"})
+			}
+		}
+
+		return violations
+	}
+
+	if err := RegisterAuditRule("no-marquee", "2.2.2", "moderate", check); err != nil {
+		t.Fatal(err)
+	}
+
+	failTable := []struct {
+		id       string
+		severity string
+	}{
+		{"no-marquee", "moderate"},
+		{"", "moderate"},
+		{"no-blink", "annoying"},
+	}
+
+	for _, f := range failTable {
+		if err := RegisterAuditRule(f.id, "2.2.2", f.severity, check); err == nil {
+			t.Errorf("failed to reject audit rule %s with severity %s", f.id, f.severity)
+		}
+	}
+
+	root := element("html", []string{"lang", "en"}, element("body", nil, element("marquee", nil, text("Sale"))))
+
+	report := runAudit(newAuditDocument(styled(root, nil)), []string{"no-marquee"})
+
+	if len(report.Findings) != 1 || report.Findings[0].Severity != "moderate" {
+		t.Errorf("registered rule did not run: %v", report)
+	}
+
+	if ids := AuditRuleIds(); ids[len(ids)-1] != "no-marquee" {
+		t.Errorf("registered rule is not listed after the built-in rules: %v", ids)
+	}
+}
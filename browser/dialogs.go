@@ -0,0 +1,149 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// DialogPolicy
+/*
+How alert, confirm, prompt and beforeunload dialogs are answered. accept clicks ok, dismiss clicks cancel and respond
+accepts a prompt with the text, other dialogs are accepted
+*/
+type DialogPolicy struct {
+	Action string `json:"action"`
+	Text   string `json:"text"`
+}
+
+func (d *DialogPolicy) validate() error {
+	switch d.Action {
+	case "accept", "dismiss":
+		if d.Text != "" {
+			return errors.New("text can only be used with the respond action")
+		}
+	case "respond":
+	default:
+		return fmt.Errorf("dialog action %s not supported", d.Action)
+	}
+
+	return nil
+}
+
+// answer
+/*
+whether the dialog is accepted and the text a prompt is answered with
+*/
+func (d *DialogPolicy) answer(dialogType page.DialogType) (bool, string) {
+	if d.Action == "dismiss" {
+		return false, ""
+	}
+
+	if d.Action == "respond" && dialogType == page.DialogTypePrompt {
+		return true, d.Text
+	}
+
+	return true, ""
+}
+
+type dialogEvent struct {
+	Timestamp     string `json:"timestamp"`
+	Type          string `json:"type"`
+	Message       string `json:"message"`
+	Url           string `json:"url"`
+	DefaultPrompt string `json:"default_prompt,omitempty"`
+	Accepted      bool   `json:"accepted"`
+	PromptText    string `json:"prompt_text,omitempty"`
+}
+
+// dialogHandler
+/*
+answers the dialogs of the page by the policy and logs each of them to a jsonl file
+*/
+type dialogHandler struct {
+	mu      sync.Mutex
+	policy  DialogPolicy
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func newDialogHandler(policy DialogPolicy, pth string) (*dialogHandler, error) {
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(pth, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dialogHandler{
+		policy:  policy,
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+func (d *dialogHandler) write(event dialogEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	event.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+
+	if err := d.encoder.Encode(event); err != nil {
+		log.Printf("unable to record dialog: %v", err)
+	}
+}
+
+// listener
+/*
+the dialog blocks the page until it is answered, so it is answered from a goroutine rather than the event loop
+*/
+func (d *dialogHandler) listener(ctx context.Context) func(ev interface{}) {
+	return func(ev interface{}) {
+		opening, ok := ev.(*page.EventJavascriptDialogOpening)
+		if !ok {
+			return
+		}
+
+		accept, text := d.policy.answer(opening.Type)
+
+		d.write(dialogEvent{
+			Type:          opening.Type.String(),
+			Message:       opening.Message,
+			Url:           opening.URL,
+			DefaultPrompt: opening.DefaultPrompt,
+			Accepted:      accept,
+			PromptText:    text,
+		})
+
+		go func() {
+			handle := page.HandleJavaScriptDialog(accept)
+			if text != "" {
+				handle = handle.WithPromptText(text)
+			}
+
+			c := chromedp.FromContext(ctx)
+			if err := handle.Do(cdp.WithExecutor(ctx, c.Target)); err != nil {
+				log.Printf("unable to answer %s dialog: %v", opening.Type, err)
+			}
+		}()
+	}
+}
+
+func (d *dialogHandler) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.file.Close(); err != nil {
+		log.Printf("unable to close dialog log: %v", err)
+	}
+}
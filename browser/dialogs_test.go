@@ -0,0 +1,77 @@
+package browser
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/chromedp/cdproto/page"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDialogPolicy(t *testing.T) {
+	failTable := []DialogPolicy{
+		{},
+		{Action: "ignore"},
+		{Action: "accept", Text: "hello"},
+	}
+
+	for _, policy := range failTable {
+		if err := policy.validate(); err == nil {
+			t.Errorf("failed to detect invalid dialog policy %v", policy)
+		}
+	}
+
+	respond := DialogPolicy{Action: "respond", Text: "hello"}
+	dismiss := DialogPolicy{Action: "dismiss"}
+
+	answerTable := []struct {
+		policy     DialogPolicy
+		dialogType page.DialogType
+		accept     bool
+		text       string
+	}{
+		{respond, page.DialogTypePrompt, true, "hello"},
+		{respond, page.DialogTypeConfirm, true, ""},
+		{dismiss, page.DialogTypeBeforeunload, false, ""},
+		{DialogPolicy{Action: "accept"}, page.DialogTypeAlert, true, ""},
+	}
+
+	for _, a := range answerTable {
+		if accept, text := a.policy.answer(a.dialogType); accept != a.accept || text != a.text {
+			t.Errorf("%s answered %s dialog with %v %q", a.policy.Action, a.dialogType, accept, text)
+		}
+	}
+}
+
+func TestDialogLog(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "dialogs.jsonl")
+
+	handler, err := newDialogHandler(DialogPolicy{Action: "dismiss"}, pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.write(dialogEvent{Type: "alert", Message: "session expired", Url: "https://bench-ai.com/"})
+	handler.close()
+
+	file, err := os.Open(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		t.Fatalf("no dialog was logged")
+	}
+
+	var event dialogEvent
+	if err = json.Unmarshal(scanner.Bytes(), &event); err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Message != "session expired" || event.Timestamp == "" {
+		t.Errorf("dialog logged incorrectly: %v", event)
+	}
+}
@@ -0,0 +1,250 @@
+package browser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/chromedp"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// downloadTimeout is how long the downloads still running after the last task are waited for
+const downloadTimeout = 30 * time.Second
+
+type downloadEntry struct {
+	Url               string `json:"url"`
+	SuggestedFilename string `json:"suggested_filename"`
+	FileName          string `json:"file_name"`
+	Size              int64  `json:"size"`
+	Sha256            string `json:"sha256"`
+	CompletedAt       string `json:"completed_at"`
+}
+
+// downloadRecorder
+/*
+moves the files the browser downloads from their guid to their suggested name and records them in a manifest once
+they complete. Every tab reports the same downloads so events are matched by guid
+*/
+type downloadRecorder struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	dir     string
+	seen    map[string]bool
+	pending map[string]*cdpbrowser.EventDownloadWillBegin
+	names   map[string]bool
+	entries []*downloadEntry
+}
+
+// newDownloadRecorder
+/*
+the files already in the folder were saved by earlier operations of the session, their names are taken so they are
+not overwritten
+*/
+func newDownloadRecorder(dir string) (*downloadRecorder, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, file := range files {
+		names[file.Name()] = true
+	}
+
+	return &downloadRecorder{
+		dir:     dir,
+		seen:    map[string]bool{},
+		pending: map[string]*cdpbrowser.EventDownloadWillBegin{},
+		names:   names,
+		entries: make([]*downloadEntry, 0, 10),
+	}, nil
+}
+
+// behaviorAction
+/*
+saves downloads into the downloads folder under their guid and reports their progress
+*/
+func (d *downloadRecorder) behaviorAction() chromedp.Action {
+	return cdpbrowser.SetDownloadBehavior(cdpbrowser.SetDownloadBehaviorBehaviorAllowAndName).
+		WithDownloadPath(d.dir).
+		WithEventsEnabled(true)
+}
+
+// uniqueName
+/*
+a file name for the download that is safe to write in the downloads folder and not taken by an earlier download,
+a second report.pdf becomes report (1).pdf
+*/
+func (d *downloadRecorder) uniqueName(suggested string) string {
+	name := filepath.Base(strings.ReplaceAll(suggested, "\\", "/"))
+	if name == "." || name == "/" || name == ".." || name == "manifest.json" {
+		name = "download"
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	candidate := name
+	for i := 1; d.names[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)%s", stem, i, ext)
+	}
+
+	d.names[candidate] = true
+
+	return candidate
+}
+
+func fileSha256(pth string) (string, int64, error) {
+	file, err := os.Open(pth)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+func (d *downloadRecorder) complete(begin *cdpbrowser.EventDownloadWillBegin) {
+	defer d.wg.Done()
+
+	d.mu.Lock()
+	name := d.uniqueName(begin.SuggestedFilename)
+	d.mu.Unlock()
+
+	pth := filepath.Join(d.dir, name)
+	if err := os.Rename(filepath.Join(d.dir, begin.GUID), pth); err != nil {
+		log.Printf("Was unable to save download %s, due to error: %v", begin.URL, err)
+		return
+	}
+
+	sum, size, err := fileSha256(pth)
+	if err != nil {
+		log.Printf("Was unable to hash download %s, due to error: %v", pth, err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.entries = append(d.entries, &downloadEntry{
+		Url:               begin.URL,
+		SuggestedFilename: begin.SuggestedFilename,
+		FileName:          name,
+		Size:              size,
+		Sha256:            sum,
+		CompletedAt:       time.Now().UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func (d *downloadRecorder) listener(ctx context.Context) func(ev interface{}) {
+	return d.listen
+}
+
+func (d *downloadRecorder) listen(ev interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *cdpbrowser.EventDownloadWillBegin:
+		if d.seen[ev.GUID] {
+			return
+		}
+
+		d.seen[ev.GUID] = true
+		d.pending[ev.GUID] = ev
+		d.wg.Add(1)
+	case *cdpbrowser.EventDownloadProgress:
+		begin, ok := d.pending[ev.GUID]
+		if !ok {
+			return
+		}
+
+		switch ev.State {
+		case cdpbrowser.DownloadProgressStateCompleted:
+			delete(d.pending, ev.GUID)
+			// the file is moved and hashed off the event loop
+			go d.complete(begin)
+		case cdpbrowser.DownloadProgressStateCanceled:
+			delete(d.pending, ev.GUID)
+			log.Printf("download of %s was canceled", begin.URL)
+			d.wg.Done()
+		}
+	}
+}
+
+// wait
+/*
+waits for the downloads in progress to complete, returns false if they are still running after the timeout
+*/
+func (d *downloadRecorder) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (d *downloadRecorder) manifest() []*downloadEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.entries
+}
+
+// appendManifest
+/*
+adds the downloads of the operation to the manifest written by earlier operations of the session
+*/
+func appendManifest(pth string, entries []*downloadEntry) ([]*downloadEntry, error) {
+	byteSlice, err := os.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return entries, err
+	}
+
+	var previous []*downloadEntry
+	if err = json.Unmarshal(byteSlice, &previous); err != nil {
+		return entries, fmt.Errorf("unable to parse %s: %v", pth, err)
+	}
+
+	return append(previous, entries...), nil
+}
+
+func setFileInputAction(selector string, files []string, queryFunc func(s *chromedp.Selector)) chromedp.ActionFunc {
+	return func(c context.Context) error {
+		node, err := resolveFirstNode(c, selector, queryFunc)
+		if err != nil {
+			return err
+		}
+
+		if node.NodeName != "INPUT" || strings.ToLower(node.AttributeValue("type")) != "file" {
+			return fmt.Errorf("%s did not match a file input", selector)
+		}
+
+		return dom.SetFileInputFiles(files).WithNodeID(node.NodeID).Do(c)
+	}
+}
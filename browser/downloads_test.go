@@ -0,0 +1,125 @@
+package browser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadRecorder(t *testing.T) {
+	dir := t.TempDir()
+
+	// saved by an earlier operation of the session
+	if err := os.WriteFile(filepath.Join(dir, "cancelled.zip"), []byte("earlier"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder, err := newDownloadRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if recorder.uniqueName("cancelled.zip") != "cancelled (1).zip" {
+		t.Errorf("a file saved by an earlier operation should not be overwritten")
+	}
+
+	data := map[string][]byte{
+		"a": []byte("first report"),
+		"b": []byte("second report"),
+		"c": []byte("never finished"),
+	}
+
+	for guid, content := range data {
+		if err := os.WriteFile(filepath.Join(dir, guid), content, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	begin := func(guid, suggested string) {
+		// every tab reports the download, so the event is sent twice
+		for i := 0; i < 2; i++ {
+			recorder.listen(&cdpbrowser.EventDownloadWillBegin{
+				GUID:              guid,
+				URL:               "https://bench-ai.com/" + guid,
+				SuggestedFilename: suggested,
+			})
+		}
+	}
+
+	begin("a", "report.pdf")
+	begin("b", "../report.pdf")
+	begin("c", "cancelled.zip")
+
+	recorder.listen(&cdpbrowser.EventDownloadProgress{GUID: "a", State: cdpbrowser.DownloadProgressStateCompleted})
+	recorder.listen(&cdpbrowser.EventDownloadProgress{GUID: "c", State: cdpbrowser.DownloadProgressStateCanceled})
+
+	if recorder.wait(50 * time.Millisecond) {
+		t.Fatalf("wait returned while a download was still running")
+	}
+
+	recorder.listen(&cdpbrowser.EventDownloadProgress{GUID: "b", State: cdpbrowser.DownloadProgressStateInProgress})
+	recorder.listen(&cdpbrowser.EventDownloadProgress{GUID: "b", State: cdpbrowser.DownloadProgressStateCompleted})
+	recorder.listen(&cdpbrowser.EventDownloadProgress{GUID: "b", State: cdpbrowser.DownloadProgressStateCompleted})
+
+	if !recorder.wait(time.Second) {
+		t.Fatalf("downloads never completed")
+	}
+
+	manifest := recorder.manifest()
+	if len(manifest) != 2 {
+		t.Fatalf("expected 2 downloads in the manifest, found %d", len(manifest))
+	}
+
+	names := map[string]*downloadEntry{}
+	for _, entry := range manifest {
+		names[entry.FileName] = entry
+	}
+
+	second, ok := names["report (1).pdf"]
+	if !ok {
+		t.Fatalf("expected the second report to be renamed, found %v", names)
+	}
+
+	sum := sha256.Sum256(data["b"])
+	if second.Sha256 != hex.EncodeToString(sum[:]) || second.Size != int64(len(data["b"])) {
+		t.Errorf("download hashed incorrectly: %v", second)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "report.pdf")); err != nil {
+		t.Errorf("download was not moved to its suggested name: %v", err)
+	}
+}
+
+func TestAppendManifest(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "manifest.json")
+
+	first := []*downloadEntry{{FileName: "report.pdf"}}
+
+	manifest, err := appendManifest(pth, first)
+	if err != nil || len(manifest) != 1 {
+		t.Fatalf("expected the manifest of the first operation, found %v: %v", manifest, err)
+	}
+
+	byteSlice, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(pth, byteSlice, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err = appendManifest(pth, []*downloadEntry{{FileName: "report (1).pdf"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest) != 2 || manifest[0].FileName != "report.pdf" || manifest[1].FileName != "report (1).pdf" {
+		t.Errorf("expected the downloads of both operations in order, found %v", manifest)
+	}
+}
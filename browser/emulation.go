@@ -0,0 +1,283 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/device"
+	"strings"
+)
+
+type Geolocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Accuracy  float64 `json:"accuracy"`
+}
+
+// Emulation
+/*
+How the browser presents itself to the page. A device preset sets the viewport, scale factor, touch support and user
+agent at once, otherwise they can be set individually. Every field is optional
+*/
+type Emulation struct {
+	Device         *string      `json:"device"`
+	Width          int64        `json:"width"`
+	Height         int64        `json:"height"`
+	ScaleFactor    float64      `json:"device_scale_factor"`
+	Mobile         bool         `json:"mobile"`
+	Touch          bool         `json:"touch"`
+	Landscape      bool         `json:"landscape"`
+	UserAgent      *string      `json:"user_agent"`
+	AcceptLanguage *string      `json:"accept_language"`
+	Timezone       *string      `json:"timezone"`
+	Geolocation    *Geolocation `json:"geolocation"`
+}
+
+// getDevicePresetMap
+/*
+the devices known to chromedp keyed by their lower case name, e.g. "iphone 13"
+*/
+func getDevicePresetMap() map[string]device.Info {
+	presets := map[string]device.Info{}
+
+	for d := device.BlackberryPlayBook; d <= device.MotoG4landscape; d++ {
+		info := d.Device()
+		presets[strings.ToLower(info.Name)] = info
+	}
+
+	return presets
+}
+
+// localeFromLanguage
+/*
+turns the first tag of an Accept-Language header into an ICU locale, "de-DE,de;q=0.9" becomes "de_DE"
+*/
+func localeFromLanguage(acceptLanguage string) string {
+	tag := strings.Split(acceptLanguage, ",")[0]
+	tag = strings.TrimSpace(strings.Split(tag, ";")[0])
+	return strings.ReplaceAll(tag, "-", "_")
+}
+
+// userAgentAction
+/*
+overrides the user agent and accept language. Chrome cannot change the language alone so the current user agent is
+kept when none is given
+*/
+func userAgentAction(userAgent, acceptLanguage string) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		if userAgent == "" {
+			_, _, _, ua, _, err := cdpbrowser.GetVersion().Do(ctx)
+			if err != nil {
+				return err
+			}
+			userAgent = ua
+		}
+
+		override := emulation.SetUserAgentOverride(userAgent)
+		if acceptLanguage != "" {
+			override = override.WithAcceptLanguage(acceptLanguage)
+		}
+
+		return override.Do(ctx)
+	}
+}
+
+// actions
+/*
+validates the emulation and builds the actions that apply it
+*/
+func (e *Emulation) actions() (chromedp.Tasks, error) {
+	var tasks chromedp.Tasks
+	userAgent := ""
+
+	if e.Device != nil {
+		if e.Width != 0 || e.Height != 0 || e.ScaleFactor != 0 || e.Mobile || e.Touch || e.Landscape {
+			return nil, errors.New("a device preset cannot be combined with viewport settings")
+		}
+
+		info, ok := getDevicePresetMap()[strings.ToLower(*e.Device)]
+		if !ok {
+			return nil, fmt.Errorf("unknown device: %s", *e.Device)
+		}
+
+		tasks = append(tasks, chromedp.Emulate(info))
+		userAgent = info.UserAgent
+	} else if e.Width != 0 || e.Height != 0 {
+		if e.Width <= 0 || e.Height <= 0 {
+			return nil, errors.New("the viewport width and height must both be positive")
+		}
+
+		if e.ScaleFactor < 0 {
+			return nil, errors.New("device_scale_factor cannot be negative")
+		}
+
+		var opts []chromedp.EmulateViewportOption
+		if e.ScaleFactor > 0 {
+			opts = append(opts, chromedp.EmulateScale(e.ScaleFactor))
+		}
+		if e.Mobile {
+			opts = append(opts, chromedp.EmulateMobile)
+		}
+		if e.Touch {
+			opts = append(opts, chromedp.EmulateTouch)
+		}
+		if e.Landscape {
+			opts = append(opts, chromedp.EmulateLandscape)
+		}
+
+		tasks = append(tasks, chromedp.EmulateViewport(e.Width, e.Height, opts...))
+	} else if e.ScaleFactor != 0 || e.Mobile || e.Touch || e.Landscape {
+		return nil, errors.New("viewport settings require a width and height")
+	}
+
+	if e.UserAgent != nil {
+		if *e.UserAgent == "" {
+			return nil, errors.New("user_agent cannot be empty")
+		}
+		userAgent = *e.UserAgent
+	}
+
+	acceptLanguage := ""
+	if e.AcceptLanguage != nil {
+		if strings.TrimSpace(*e.AcceptLanguage) == "" {
+			return nil, errors.New("accept_language cannot be empty")
+		}
+		acceptLanguage = *e.AcceptLanguage
+	}
+
+	// a device's user agent is applied again so the language can be added to it
+	if e.UserAgent != nil || e.AcceptLanguage != nil {
+		tasks = append(tasks, userAgentAction(userAgent, acceptLanguage))
+	}
+
+	if acceptLanguage != "" {
+		tasks = append(tasks, emulation.SetLocaleOverride().WithLocale(localeFromLanguage(acceptLanguage)))
+	}
+
+	if e.Timezone != nil {
+		if *e.Timezone == "" {
+			return nil, errors.New("timezone cannot be empty")
+		}
+		tasks = append(tasks, emulation.SetTimezoneOverride(*e.Timezone))
+	}
+
+	if geo := e.Geolocation; geo != nil {
+		if geo.Latitude < -90 || geo.Latitude > 90 || geo.Longitude < -180 || geo.Longitude > 180 {
+			return nil, errors.New("geolocation is out of range")
+		}
+
+		if geo.Accuracy < 0 {
+			return nil, errors.New("geolocation accuracy cannot be negative")
+		}
+
+		accuracy := geo.Accuracy
+		if accuracy == 0 {
+			accuracy = 1
+		}
+
+		// the page would otherwise be prompted before it can read the position
+		tasks = append(tasks,
+			cdpbrowser.GrantPermissions([]cdpbrowser.PermissionType{cdpbrowser.PermissionTypeGeolocation}),
+			emulation.SetGeolocationOverride().
+				WithLatitude(geo.Latitude).
+				WithLongitude(geo.Longitude).
+				WithAccuracy(accuracy),
+		)
+	}
+
+	return tasks, nil
+}
+
+// MediaEmulation
+/*
+The css media type and media features the page sees, empty fields are not emulated
+*/
+type MediaEmulation struct {
+	Media         string `json:"media,omitempty"`
+	ColorScheme   string `json:"prefers_color_scheme,omitempty"`
+	ReducedMotion string `json:"prefers_reduced_motion,omitempty"`
+	ForcedColors  string `json:"forced_colors,omitempty"`
+}
+
+func (m MediaEmulation) features() []*emulation.MediaFeature {
+	var features []*emulation.MediaFeature
+
+	for _, feature := range []*emulation.MediaFeature{
+		{Name: "prefers-color-scheme", Value: m.ColorScheme},
+		{Name: "prefers-reduced-motion", Value: m.ReducedMotion},
+		{Name: "forced-colors", Value: m.ForcedColors},
+	} {
+		if feature.Value != "" {
+			features = append(features, feature)
+		}
+	}
+
+	return features
+}
+
+// emulationState
+/*
+the emulation active when a snapshot is taken, saved as emulation.json in the snapshot folder
+*/
+type emulationState struct {
+	Settings         *Emulation      `json:"settings,omitempty"`
+	Media            *MediaEmulation `json:"media,omitempty"`
+	VisionDeficiency string          `json:"vision_deficiency,omitempty"`
+}
+
+func (e emulationState) active() bool {
+	return e.Settings != nil || e.Media != nil || e.VisionDeficiency != ""
+}
+
+// tabEmulation
+/*
+the emulation of the active tab. Media and vision emulation only change the tab they run in so the state is kept per
+tab, before the first tab is known it is the emulation every tab starts with
+*/
+func (b *Executor) tabEmulation() *emulationState {
+	if b.activeTab == nil {
+		return &b.emulation
+	}
+
+	return &b.activeTab.emulation
+}
+
+// updateEmulation
+/*
+changes the emulation of the tab active when the task runs, once the task before it has applied the emulation
+*/
+func (b *Executor) updateEmulation(update func(state *emulationState)) {
+	b.appendTask(chromedp.ActionFunc(func(c context.Context) error {
+		update(b.tabEmulation())
+		return nil
+	}))
+}
+
+// recordEmulation
+/*
+remembers the emulation of the tab a snapshot is taken in, the task is added before the snapshot's own tasks
+*/
+func (b *Executor) recordEmulation(snapshot string) {
+	record := b.snapshotRecorder()
+
+	b.appendTask(chromedp.ActionFunc(func(c context.Context) error {
+		record(snapshot)
+		return nil
+	}))
+}
+
+// snapshotRecorder
+/*
+remembers the emulation of the active tab for the snapshots a task names while it runs, such as the numbered snapshots
+of scroll_until_stable and iterate_html
+*/
+func (b *Executor) snapshotRecorder() func(snapshot string) {
+	return func(snapshot string) {
+		if state := *b.tabEmulation(); state.active() {
+			b.emulationMap[snapshot] = state
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package browser
+
+import (
+	"context"
+	"github.com/chromedp/chromedp"
+	"testing"
+)
+
+func TestEmulationActions(t *testing.T) {
+	iphone := "iPhone 13"
+	unknown := "Nokia 3310"
+	empty := ""
+	language := "de-DE,de;q=0.9"
+	timezone := "Europe/Berlin"
+
+	tasks, err := (&Emulation{Device: &iphone, AcceptLanguage: &language}).actions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// device, user agent with language and locale
+	if len(tasks) != 3 {
+		t.Errorf("expected 3 emulation tasks, found %d", len(tasks))
+	}
+
+	tasks, err = (&Emulation{
+		Width:       390,
+		Height:      844,
+		ScaleFactor: 3,
+		Touch:       true,
+		Timezone:    &timezone,
+		Geolocation: &Geolocation{Latitude: 52.52, Longitude: 13.405},
+	}).actions()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// viewport, timezone, permission grant and geolocation
+	if len(tasks) != 4 {
+		t.Errorf("expected 4 emulation tasks, found %d", len(tasks))
+	}
+
+	invalid := []Emulation{
+		{Device: &unknown},
+		{Device: &iphone, Width: 100, Height: 100},
+		{Width: 100},
+		{Touch: true},
+		{Width: 100, Height: 100, ScaleFactor: -1},
+		{UserAgent: &empty},
+		{AcceptLanguage: &empty},
+		{Timezone: &empty},
+		{Geolocation: &Geolocation{Latitude: 91}},
+		{Geolocation: &Geolocation{Accuracy: -1}},
+	}
+
+	for _, emulation := range invalid {
+		if _, err = emulation.actions(); err == nil {
+			t.Errorf("expected emulation %+v to be invalid", emulation)
+		}
+	}
+}
+
+func TestDevicePresets(t *testing.T) {
+	presets := getDevicePresetMap()
+
+	info, ok := presets["iphone 13"]
+	if !ok {
+		t.Fatal("expected the iPhone 13 preset to exist")
+	}
+
+	if !info.Mobile || !info.Touch || info.Width == 0 {
+		t.Errorf("iPhone 13 preset loaded incorrectly: %+v", info)
+	}
+
+	if _, ok = presets["moto g4 landscape"]; !ok {
+		t.Error("expected the last device preset to be included")
+	}
+}
+
+func TestLocaleFromLanguage(t *testing.T) {
+	if locale := localeFromLanguage("de-DE,de;q=0.9"); locale != "de_DE" {
+		t.Errorf("expected de_DE, found %s", locale)
+	}
+
+	if locale := localeFromLanguage("fr;q=0.8"); locale != "fr" {
+		t.Errorf("expected fr, found %s", locale)
+	}
+}
+
+// runStateTasks
+/*
+runs the tasks added since the last call that only change the state of the executor, the ones sent to the browser
+are skipped
+*/
+func runStateTasks(t *testing.T, b *Executor) {
+	for _, task := range b.tasks {
+		if action, ok := task.(chromedp.ActionFunc); ok {
+			if err := action.Do(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	b.tasks = nil
+}
+
+func TestRecordEmulation(t *testing.T) {
+	b := Executor{emulationMap: map[string]emulationState{}}
+	b.activeTab = b.newTab("1")
+
+	b.recordEmulation("before")
+
+	b.EmulateVisionDeficiency("protanopia")
+	b.recordEmulation("home")
+	runStateTasks(t, &b)
+
+	// scroll_until_stable and iterate_html name their snapshots while they run
+	b.snapshotRecorder()("iter_0_1500_ms")
+
+	b.EmulateMedia(MediaEmulation{ColorScheme: "dark"})
+	b.EmulateVisionDeficiency("none")
+	b.recordEmulation("dark")
+	b.EmulateMedia(MediaEmulation{})
+	b.recordEmulation("home_2")
+	runStateTasks(t, &b)
+
+	if _, ok := b.emulationMap["before"]; ok {
+		t.Error("no emulation should be recorded before one is active")
+	}
+
+	if state, ok := b.emulationMap["home"]; !ok || state.VisionDeficiency != "protanopia" || state.Media != nil {
+		t.Errorf("vision deficiency recorded incorrectly: %+v", state)
+	}
+
+	if state, ok := b.emulationMap["dark"]; !ok || state.VisionDeficiency != "" || state.Media.ColorScheme != "dark" {
+		t.Errorf("media emulation recorded incorrectly: %+v", state)
+	}
+
+	if state, ok := b.emulationMap["home_2"]; ok {
+		t.Errorf("a snapshot named like a generated one took the emulation of another snapshot: %+v", state)
+	}
+
+	if state, ok := b.emulationMap["iter_0_1500_ms"]; !ok || state.VisionDeficiency != "protanopia" {
+		t.Errorf("generated snapshot did not record the emulation active while its task ran: %+v", state)
+	}
+}
+
+func TestTabEmulation(t *testing.T) {
+	timezone := "Europe/Berlin"
+
+	b := Executor{emulationMap: map[string]emulationState{}}
+	b.emulation = emulationState{Settings: &Emulation{Timezone: &timezone}}
+
+	first := b.newTab("1")
+	b.activeTab = first
+
+	b.EmulateMedia(MediaEmulation{ColorScheme: "dark"})
+	b.recordEmulation("dark")
+	runStateTasks(t, &b)
+
+	// new_tab routes the following tasks to a tab that only has the emulation of the settings
+	b.activeTab = b.newTab("2")
+	b.recordEmulation("new_tab")
+	runStateTasks(t, &b)
+
+	b.activeTab = first
+	b.recordEmulation("switched_back")
+	runStateTasks(t, &b)
+
+	if state := b.emulationMap["dark"]; state.Media == nil || state.Media.ColorScheme != "dark" {
+		t.Errorf("media emulation recorded incorrectly: %+v", state)
+	}
+
+	if state := b.emulationMap["new_tab"]; state.Media != nil || state.Settings == nil {
+		t.Errorf("a new tab should only record the emulation of the settings: %+v", state)
+	}
+
+	if state := b.emulationMap["switched_back"]; state.Media == nil {
+		t.Errorf("the media emulation of the first tab was lost: %+v", state)
+	}
+}
@@ -0,0 +1,177 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type browserEvent struct {
+	Timestamp  string `json:"timestamp"`
+	Type       string `json:"type"`
+	Level      string `json:"level,omitempty"`
+	Message    string `json:"message"`
+	Url        string `json:"url"`
+	RequestUrl string `json:"request_url,omitempty"`
+	SourceUrl  string `json:"source_url,omitempty"`
+	Line       *int64 `json:"line,omitempty"`
+	Column     *int64 `json:"column,omitempty"`
+}
+
+// eventRecorder
+/*
+streams console calls, javascript exceptions and failed requests to a jsonl file as the browser emits them
+*/
+type eventRecorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// tabEvents
+/*
+the page a tab is on, each tab keeps its own so events are recorded with the url of the tab they came from
+*/
+type tabEvents struct {
+	mainFrameId cdp.FrameID
+	activeUrl   string
+	requestUrls map[network.RequestID]string
+}
+
+func newEventRecorder(pth string) (*eventRecorder, error) {
+	file, err := os.OpenFile(pth, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventRecorder{
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+// remoteObjectString
+/*
+renders a console argument the way the devtools console would print it
+*/
+func remoteObjectString(obj *runtime.RemoteObject) string {
+	if obj == nil {
+		return ""
+	}
+
+	if len(obj.Value) > 0 {
+		var str string
+		if err := json.Unmarshal(obj.Value, &str); err == nil {
+			return str
+		}
+		return string(obj.Value)
+	}
+
+	if obj.UnserializableValue != "" {
+		return string(obj.UnserializableValue)
+	}
+
+	if obj.Description != "" {
+		return obj.Description
+	}
+
+	return obj.Type.String()
+}
+
+func (e *eventRecorder) write(tab *tabEvents, event browserEvent) {
+	event.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	event.Url = tab.activeUrl
+
+	if err := e.encoder.Encode(event); err != nil {
+		log.Printf("unable to record browser event: %v", err)
+	}
+}
+
+func (e *eventRecorder) listener(ctx context.Context) func(ev interface{}) {
+	tab := &tabEvents{requestUrls: map[network.RequestID]string{}}
+
+	return func(ev interface{}) {
+		e.listen(tab, ev)
+	}
+}
+
+// listen
+/*
+the chromedp target listener of a tab, it must not block or call back into the browser
+*/
+func (e *eventRecorder) listen(tab *tabEvents, ev interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *page.EventFrameNavigated:
+		if ev.Frame.ParentID == "" {
+			tab.mainFrameId = ev.Frame.ID
+			tab.activeUrl = ev.Frame.URL + ev.Frame.URLFragment
+		}
+	case *page.EventNavigatedWithinDocument:
+		if ev.FrameID == tab.mainFrameId {
+			tab.activeUrl = ev.URL
+		}
+	case *network.EventRequestWillBeSent:
+		tab.requestUrls[ev.RequestID] = ev.Request.URL
+	case *network.EventLoadingFinished:
+		delete(tab.requestUrls, ev.RequestID)
+	case *runtime.EventConsoleAPICalled:
+		var args []string
+		for _, arg := range ev.Args {
+			args = append(args, remoteObjectString(arg))
+		}
+
+		e.write(tab, browserEvent{
+			Type:    "console",
+			Level:   ev.Type.String(),
+			Message: strings.Join(args, " "),
+		})
+	case *runtime.EventExceptionThrown:
+		details := ev.ExceptionDetails
+		message := details.Text
+		if details.Exception != nil && details.Exception.Description != "" {
+			message += " " + details.Exception.Description
+		}
+
+		e.write(tab, browserEvent{
+			Type:      "exception",
+			Level:     "error",
+			Message:   message,
+			SourceUrl: details.URL,
+			Line:      &details.LineNumber,
+			Column:    &details.ColumnNumber,
+		})
+	case *network.EventLoadingFailed:
+		message := ev.ErrorText
+		if ev.BlockedReason != "" {
+			message += " (blocked: " + ev.BlockedReason.String() + ")"
+		}
+
+		e.write(tab, browserEvent{
+			Type:       "request_failed",
+			Level:      "error",
+			Message:    message,
+			RequestUrl: tab.requestUrls[ev.RequestID],
+		})
+		delete(tab.requestUrls, ev.RequestID)
+	}
+}
+
+func (e *eventRecorder) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.file.Close(); err != nil {
+		log.Printf("unable to close browser event log: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package browser
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEventRecorder(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "browser_events.jsonl")
+
+	recorder, err := newEventRecorder(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listen := recorder.listener(context.Background())
+	otherTab := recorder.listener(context.Background())
+
+	listen(&page.EventFrameNavigated{
+		Frame: &cdp.Frame{ID: "main", URL: "https://bench-ai.com/"},
+	})
+	otherTab(&page.EventFrameNavigated{
+		Frame: &cdp.Frame{ID: "popup", URL: "https://bench-ai.com/popup"},
+	})
+	listen(&page.EventFrameNavigated{
+		Frame: &cdp.Frame{ID: "ad", ParentID: "main", URL: "https://ads.example.com/"},
+	})
+	listen(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeWarning,
+		Args: []*runtime.RemoteObject{
+			{Type: runtime.TypeString, Value: []byte(`"count"`)},
+			{Type: runtime.TypeNumber, Value: []byte(`3`)},
+		},
+	})
+	listen(&runtime.EventExceptionThrown{
+		ExceptionDetails: &runtime.ExceptionDetails{
+			Text:       "Uncaught",
+			LineNumber: 10,
+			Exception:  &runtime.RemoteObject{Description: "TypeError: x is undefined"},
+		},
+	})
+	listen(&network.EventRequestWillBeSent{
+		RequestID: "1",
+		Request:   &network.Request{URL: "https://bench-ai.com/missing.js"},
+	})
+	listen(&network.EventLoadingFailed{
+		RequestID: "1",
+		ErrorText: "net::ERR_NAME_NOT_RESOLVED",
+	})
+	recorder.close()
+
+	file, err := os.Open(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var events []browserEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event browserEvent
+		if err = json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, found %d", len(events))
+	}
+
+	if events[0].Type != "console" || events[0].Level != "warning" || events[0].Message != "count 3" {
+		t.Errorf("console event recorded incorrectly: %v", events[0])
+	}
+
+	if events[1].Message != "Uncaught TypeError: x is undefined" || *events[1].Line != 10 {
+		t.Errorf("exception event recorded incorrectly: %v", events[1])
+	}
+
+	if events[2].RequestUrl != "https://bench-ai.com/missing.js" {
+		t.Errorf("failed request did not record its url: %v", events[2])
+	}
+
+	for _, event := range events {
+		if event.Url != "https://bench-ai.com/" || event.Timestamp == "" {
+			t.Errorf("event is missing the active url or timestamp: %v", event)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"log"
+)
+
+// frame
+/*
+an iframe entered by enter_frame. node stands in for the frame's document in queries, it is created when the task is
+added and filled in once the frame is entered. ctx is only set for cross-origin frames, chrome runs those in their own
+target
+*/
+type frame struct {
+	node *cdp.Node
+	ctx  context.Context
+}
+
+// inFrame
+/*
+makes the query of a selector based task run inside the frame that will be entered when the task runs. Tasks run in
+the order they are added so the frames entered at this point are the frames entered when the task runs
+*/
+func (b *Executor) inFrame(queryFunc func(s *chromedp.Selector)) func(s *chromedp.Selector) {
+	if len(b.frameStack) == 0 {
+		return queryFunc
+	}
+
+	node := b.frameStack[len(b.frameStack)-1].node
+
+	return func(s *chromedp.Selector) {
+		queryFunc(s)
+		chromedp.FromNode(node)(s)
+	}
+}
+
+// appendFrameTask
+/*
+adds a task that queries elements. Inside a cross-origin frame it runs in the target of the frame, every other task
+keeps running on the page of the tab
+*/
+func (b *Executor) appendFrameTask(action chromedp.Action) {
+	if len(b.frameStack) == 0 {
+		b.appendTask(action)
+		return
+	}
+
+	b.appendTask(chromedp.ActionFunc(func(c context.Context) error {
+		if ctx := b.frameContext(); ctx != nil {
+			return chromedp.Run(ctx, action)
+		}
+
+		return action.Do(c)
+	}))
+}
+
+// frameContext
+/*
+the context of the innermost cross-origin frame entered, nil when the tasks run in the page of the tab
+*/
+func (b *Executor) frameContext() context.Context {
+	for i := len(b.frames) - 1; i >= 0; i-- {
+		if b.frames[i].ctx != nil {
+			return b.frames[i].ctx
+		}
+	}
+
+	return nil
+}
+
+// leaveFrames
+/*
+exits every frame entered, for tasks that move away from the page the frames belong to
+*/
+func (b *Executor) leaveFrames() {
+	if len(b.frameStack) == 0 {
+		return
+	}
+
+	b.frameStack = nil
+	b.appendTask(chromedp.ActionFunc(func(c context.Context) error {
+		b.frames = nil
+		return nil
+	}))
+}
+
+func enterFrameAction(
+	b *Executor,
+	f *frame,
+	selector string,
+	queryFunc func(s *chromedp.Selector)) chromedp.ActionFunc {
+
+	return func(c context.Context) error {
+		iframe, err := resolveFirstNode(c, selector, queryFunc)
+		if err != nil {
+			return err
+		}
+
+		if iframe.NodeName != "IFRAME" && iframe.NodeName != "FRAME" {
+			return fmt.Errorf("%s matched a %s element rather than an iframe", selector, iframe.NodeName)
+		}
+
+		if iframe.FrameID == "" {
+			return fmt.Errorf("the iframe matching %s has not loaded a document", selector)
+		}
+
+		var roots []*cdp.Node
+
+		if iframe.ContentDocument != nil {
+			err = chromedp.Nodes("html", &roots, chromedp.ByQuery, chromedp.FromNode(iframe)).Do(c)
+		} else {
+			// a cross-origin frame is its own target, the target shares the id of the frame. The context is left
+			// attached until the browser closes, cancelling it would try to close the frame
+			f.ctx, _ = chromedp.NewContext(b.ctx, chromedp.WithTargetID(target.ID(iframe.FrameID)))
+			b.listenTarget(f.ctx)
+			err = chromedp.Run(f.ctx, chromedp.Nodes("html", &roots, chromedp.ByQuery))
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(roots) == 0 {
+			return fmt.Errorf("the iframe matching %s has no document", selector)
+		}
+
+		// the frame id lets chromedp run the query in the execution context of the frame
+		f.node.NodeID = roots[0].NodeID
+		f.node.ParentID = roots[0].ParentID
+		f.node.BackendNodeID = roots[0].BackendNodeID
+		f.node.NodeName = roots[0].NodeName
+		f.node.FrameID = iframe.FrameID
+
+		b.frames = append(b.frames, f)
+
+		return nil
+	}
+}
+
+// EnterFrame
+/*
+Runs the tasks that query elements inside the iframe matching the selector, until exit_frame is used. Other tasks
+keep running on the page of the tab. Frames can be nested
+*/
+func (b *Executor) EnterFrame(selector string, queryFunc func(s *chromedp.Selector)) {
+	f := &frame{node: &cdp.Node{}}
+	b.appendFrameTask(enterFrameAction(b, f, selector, b.inFrame(queryFunc)))
+	b.frameStack = append(b.frameStack, f)
+}
+
+// ExitFrame
+/*
+Returns the queries of the following tasks to the frame or page containing the current frame
+*/
+func (b *Executor) ExitFrame() {
+	if len(b.frameStack) == 0 {
+		log.Fatal("exit_frame must follow an enter_frame")
+	}
+
+	b.frameStack = b.frameStack[:len(b.frameStack)-1]
+	b.appendTask(chromedp.ActionFunc(func(c context.Context) error {
+		if len(b.frames) == 0 {
+			return errors.New("no frame was entered")
+		}
+
+		b.frames = b.frames[:len(b.frames)-1]
+		return nil
+	}))
+}
@@ -0,0 +1,88 @@
+package browser
+
+import (
+	"context"
+	"github.com/chromedp/chromedp"
+	"testing"
+)
+
+type frameKey struct{}
+
+func TestFrameStack(t *testing.T) {
+	b := &Executor{}
+
+	b.EnterFrame("iframe#outer", chromedp.ByQuery)
+	b.EnterFrame("iframe#inner", chromedp.ByQuery)
+
+	if len(b.frameStack) != 2 || len(b.tasks) != 2 {
+		t.Fatalf("expected 2 frames and 2 tasks, found %d and %d", len(b.frameStack), len(b.tasks))
+	}
+
+	outer := b.frameStack[0]
+	b.ExitFrame()
+
+	if len(b.frameStack) != 1 || b.frameStack[0] != outer {
+		t.Fatalf("exit_frame should return to the outer frame")
+	}
+
+	// the frames entered when the tasks run
+	ctx := context.WithValue(context.Background(), frameKey{}, "outer")
+	b.frames = []*frame{{node: outer.node, ctx: ctx}, {node: b.frameStack[0].node}}
+
+	if b.frameContext() != ctx {
+		t.Errorf("a same origin frame should run in the context of the cross-origin frame containing it")
+	}
+
+	if err := b.tasks[2].Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.frames) != 1 {
+		t.Errorf("expected the exit action to leave 1 frame, found %d", len(b.frames))
+	}
+
+	b.leaveFrames()
+
+	if len(b.frameStack) != 0 || len(b.tasks) != 4 {
+		t.Fatalf("expected no frames and 4 tasks, found %d and %d", len(b.frameStack), len(b.tasks))
+	}
+
+	if err := b.tasks[3].Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.frameContext() != nil {
+		t.Errorf("leaving the frames should route tasks back to the tab")
+	}
+
+	b.leaveFrames()
+
+	if len(b.tasks) != 4 {
+		t.Errorf("leaving frames outside of a frame should not add a task")
+	}
+}
+
+func TestAppendFrameTask(t *testing.T) {
+	b := &Executor{}
+
+	ran := 0
+	action := chromedp.ActionFunc(func(c context.Context) error {
+		ran++
+		return nil
+	})
+
+	b.appendFrameTask(action)
+	b.EnterFrame("iframe", chromedp.ByQuery)
+	b.appendFrameTask(action)
+
+	if len(b.tasks) != 3 {
+		t.Fatalf("expected 3 tasks, found %d", len(b.tasks))
+	}
+
+	// a same origin frame has no target of its own so the task runs on the tab
+	b.frames = []*frame{b.frameStack[0]}
+
+	if err := b.tasks[2].Do(context.Background()); err != nil || ran != 1 {
+		t.Errorf("the task did not run on the tab: %v", err)
+	}
+}
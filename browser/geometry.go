@@ -0,0 +1,169 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"strings"
+)
+
+// checkVisibilityJs is called on an element, it is false when the element or an ancestor is display:none,
+// visibility:hidden or opacity:0. Browsers without Element.checkVisibility only have the element's own styles checked
+const checkVisibilityJs = `function() {
+	if (typeof this.checkVisibility === "function") {
+		return this.checkVisibility({opacityProperty: true, visibilityProperty: true});
+	}
+	const style = getComputedStyle(this);
+	return style.display !== "none" && style.visibility !== "hidden" && style.opacity !== "0";
+}`
+
+type nodeBoxModel struct {
+	Content []float64 `json:"content"`
+	Border  []float64 `json:"border"`
+	Width   int64     `json:"width"`
+	Height  int64     `json:"height"`
+}
+
+// nodeGeometry
+/*
+where a node sits on the page. Quads are in page coordinates so they line up with full page screenshots
+*/
+type nodeGeometry struct {
+	boxModel   *nodeBoxModel
+	visible    bool
+	inViewport bool
+	text       string
+}
+
+type viewport struct {
+	pageX, pageY  float64
+	width, height float64
+}
+
+// quadBounds
+/*
+returns the smallest rectangle (minX, minY, maxX, maxY) containing the quad
+*/
+func quadBounds(q dom.Quad) (float64, float64, float64, float64) {
+	minX, minY, maxX, maxY := q[0], q[1], q[0], q[1]
+
+	for i := 2; i+1 < len(q); i += 2 {
+		minX = min(minX, q[i])
+		maxX = max(maxX, q[i])
+		minY = min(minY, q[i+1])
+		maxY = max(maxY, q[i+1])
+	}
+
+	return minX, minY, maxX, maxY
+}
+
+// intersectsViewport
+/*
+checks whether any part of a quad given in viewport coordinates is on screen
+*/
+func intersectsViewport(q dom.Quad, vp viewport) bool {
+	if len(q) < 8 {
+		return false
+	}
+
+	minX, minY, maxX, maxY := quadBounds(q)
+
+	return maxX > 0 && maxY > 0 && minX < vp.width && minY < vp.height
+}
+
+// toPageQuad
+/*
+shifts a quad from viewport coordinates to page coordinates
+*/
+func toPageQuad(q dom.Quad, vp viewport) []float64 {
+	pageQuad := make([]float64, len(q))
+	for i, v := range q {
+		if i%2 == 0 {
+			pageQuad[i] = v + vp.pageX
+		} else {
+			pageQuad[i] = v + vp.pageY
+		}
+	}
+
+	return pageQuad
+}
+
+// currentViewport
+/*
+returns the scroll offset and size of the viewport in css pixels
+*/
+func currentViewport(c context.Context) (viewport, error) {
+	_, _, _, layout, _, _, err := page.GetLayoutMetrics().Do(c)
+	if err != nil {
+		return viewport{}, err
+	}
+
+	return viewport{
+		pageX:  float64(layout.PageX),
+		pageY:  float64(layout.PageY),
+		width:  float64(layout.ClientWidth),
+		height: float64(layout.ClientHeight),
+	}, nil
+}
+
+// elementVisible
+/*
+checks the computed styles that hide an element while it keeps its box model. The box model alone decides when the
+check cannot run
+*/
+func elementVisible(c context.Context, node *cdp.Node) bool {
+	obj, err := dom.ResolveNode().WithNodeID(node.NodeID).Do(c)
+	if err != nil {
+		return true
+	}
+	defer runtime.ReleaseObject(obj.ObjectID).Do(c)
+
+	res, exception, err := runtime.CallFunctionOn(checkVisibilityJs).
+		WithObjectID(obj.ObjectID).
+		WithReturnByValue(true).
+		Do(c)
+	if err != nil || exception != nil {
+		return true
+	}
+
+	var visible bool
+	if err = json.Unmarshal(res.Value, &visible); err != nil {
+		return true
+	}
+
+	return visible
+}
+
+// getNodeGeometry
+/*
+collects the box model, visibility and text of a node. Nodes that are not rendered have no box model
+*/
+func getNodeGeometry(c context.Context, node *cdp.Node, vp viewport) *nodeGeometry {
+	geometry := nodeGeometry{
+		text: strings.Join(strings.Fields(nodeText(node)), " "),
+	}
+
+	if node.NodeType != cdp.NodeTypeElement {
+		return &geometry
+	}
+
+	box, err := dom.GetBoxModel().WithNodeID(node.NodeID).Do(c)
+	if err != nil {
+		return &geometry
+	}
+
+	geometry.boxModel = &nodeBoxModel{
+		Content: toPageQuad(box.Content, vp),
+		Border:  toPageQuad(box.Border, vp),
+		Width:   box.Width,
+		Height:  box.Height,
+	}
+
+	geometry.visible = box.Width > 0 && box.Height > 0 && elementVisible(c, node)
+	geometry.inViewport = geometry.visible && intersectsViewport(box.Border, vp)
+
+	return &geometry
+}
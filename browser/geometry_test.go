@@ -0,0 +1,66 @@
+package browser
+
+import (
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"testing"
+)
+
+func TestIntersectsViewport(t *testing.T) {
+	vp := viewport{pageX: 0, pageY: 500, width: 800, height: 600}
+
+	onScreen := dom.Quad{10, 10, 110, 10, 110, 60, 10, 60}
+	below := dom.Quad{10, 700, 110, 700, 110, 760, 10, 760}
+	partial := dom.Quad{-50, -20, 20, -20, 20, 10, -50, 10}
+
+	if !intersectsViewport(onScreen, vp) {
+		t.Error("quad on screen was not detected")
+	}
+
+	if intersectsViewport(below, vp) {
+		t.Error("quad below the viewport was detected as on screen")
+	}
+
+	if !intersectsViewport(partial, vp) {
+		t.Error("quad partially on screen was not detected")
+	}
+
+	pageQuad := toPageQuad(onScreen, vp)
+
+	if pageQuad[0] != 10 || pageQuad[1] != 510 {
+		t.Errorf("quad was not shifted into page coordinates, got %v", pageQuad)
+	}
+}
+
+func TestParseThroughNodesGeometry(t *testing.T) {
+	node := &cdp.Node{
+		NodeID:   cdp.NodeID(1),
+		NodeType: cdp.NodeTypeElement,
+	}
+
+	nodeSlice := []*nodeWithStyles{
+		{node: node},
+		{
+			node: node,
+			geometry: &nodeGeometry{
+				boxModel: &nodeBoxModel{Width: 100, Height: 50},
+				visible:  true,
+				text:     "Bench AI",
+			},
+		},
+	}
+
+	metaData := parseThroughNodes(nodeSlice)
+
+	if metaData[0].BoxModel != nil || metaData[0].Visible != nil || metaData[0].Text != nil {
+		t.Error("geometry was recorded for a node that did not collect it")
+	}
+
+	if metaData[1].BoxModel.Width != 100 || !*metaData[1].Visible || *metaData[1].InViewport {
+		t.Error("geometry was not recorded correctly")
+	}
+
+	if *metaData[1].Text != "Bench AI" {
+		t.Error("text content was not recorded")
+	}
+}
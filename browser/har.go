@@ -0,0 +1,477 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	Url         string         `json:"url"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harResponse struct {
+	Status       int64          `json:"status"`
+	StatusText   string         `json:"statusText"`
+	HttpVersion  string         `json:"httpVersion"`
+	Cookies      []harNameValue `json:"cookies"`
+	Headers      []harNameValue `json:"headers"`
+	Content      harContent     `json:"content"`
+	RedirectUrl  string         `json:"redirectURL"`
+	HeadersSize  int64          `json:"headersSize"`
+	BodySize     int64          `json:"bodySize"`
+	TransferSize float64        `json:"_transferSize"`
+	Error        string         `json:"_error,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	Dns     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	Ssl     float64 `json:"ssl"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIpAddress string      `json:"serverIPAddress,omitempty"`
+	ResourceType    string      `json:"_resourceType,omitempty"`
+
+	started time.Time
+	timing  *network.ResourceTiming
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+// harRecorder
+/*
+collects the network traffic of the page into HAR 1.2 entries as the browser emits it. Response bodies are only
+fetched when fetchBody is set
+*/
+type harRecorder struct {
+	mu        sync.Mutex
+	wg        sync.WaitGroup
+	entries   []*harEntry
+	pending   map[network.RequestID]*harEntry
+	fetchBody func(ctx context.Context, id network.RequestID) ([]byte, error)
+}
+
+func newHarRecorder(fetchBody func(ctx context.Context, id network.RequestID) ([]byte, error)) *harRecorder {
+	return &harRecorder{
+		pending:   map[network.RequestID]*harEntry{},
+		fetchBody: fetchBody,
+	}
+}
+
+// harHeaders
+/*
+converts devtools headers into HAR name value pairs, sorted so the output is stable
+*/
+func harHeaders(headers network.Headers) []harNameValue {
+	pairs := make([]harNameValue, 0, len(headers))
+	for name, value := range headers {
+		pairs = append(pairs, harNameValue{Name: name, Value: fmt.Sprint(value)})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+
+	return pairs
+}
+
+func headerValue(headers network.Headers, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return fmt.Sprint(value)
+		}
+	}
+
+	return ""
+}
+
+func harQueryString(rawUrl string) []harNameValue {
+	pairs := make([]harNameValue, 0)
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return pairs
+	}
+
+	for name, values := range u.Query() {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+
+	return pairs
+}
+
+// harHttpVersion
+/*
+maps the devtools protocol name onto the version string HAR viewers expect
+*/
+func harHttpVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "", "http/1.1":
+		return "HTTP/1.1"
+	case "http/1.0":
+		return "HTTP/1.0"
+	case "h2":
+		return "HTTP/2"
+	case "h3", "h3-29":
+		return "HTTP/3"
+	default:
+		return protocol
+	}
+}
+
+// harDuration
+/*
+the length of a devtools timing phase in milliseconds, -1 when the phase did not happen
+*/
+func harDuration(start, end float64) float64 {
+	if start < 0 || end < 0 {
+		return -1
+	}
+
+	return end - start
+}
+
+func newHarEntry(ev *network.EventRequestWillBeSent) *harEntry {
+	req := ev.Request
+
+	entry := &harEntry{
+		Request: harRequest{
+			Method:      req.Method,
+			Url:         req.URL + req.URLFragment,
+			HttpVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(req.Headers),
+			QueryString: harQueryString(req.URL),
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Response: harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		ResourceType: ev.Type.String(),
+	}
+
+	if ev.Timestamp != nil {
+		entry.started = ev.Timestamp.Time()
+	}
+
+	startedAt := time.Now()
+	if ev.WallTime != nil {
+		startedAt = ev.WallTime.Time()
+	}
+	entry.StartedDateTime = startedAt.UTC().Format(time.RFC3339Nano)
+
+	if req.HasPostData {
+		var sb strings.Builder
+		for _, data := range req.PostDataEntries {
+			decoded, err := base64.StdEncoding.DecodeString(data.Bytes)
+			if err != nil {
+				continue
+			}
+			sb.Write(decoded)
+		}
+
+		entry.Request.PostData = &harPostData{
+			MimeType: headerValue(req.Headers, "Content-Type"),
+			Text:     sb.String(),
+		}
+		entry.Request.BodySize = int64(sb.Len())
+	}
+
+	return entry
+}
+
+func (e *harEntry) setResponse(res *network.Response) {
+	e.Response.Status = res.Status
+	e.Response.StatusText = res.StatusText
+	e.Response.HttpVersion = harHttpVersion(res.Protocol)
+	e.Response.Headers = harHeaders(res.Headers)
+	e.Response.RedirectUrl = headerValue(res.Headers, "Location")
+	e.Response.Content.MimeType = res.MimeType
+	e.Response.TransferSize = res.EncodedDataLength
+	e.Request.HttpVersion = e.Response.HttpVersion
+	e.ServerIpAddress = res.RemoteIPAddress
+	e.timing = res.Timing
+
+	// the headers that were actually sent are more complete than the ones known when the request started
+	if len(res.RequestHeaders) > 0 {
+		e.Request.Headers = harHeaders(res.RequestHeaders)
+	}
+}
+
+// finish
+/*
+fills in the timings of an entry once its last event has arrived
+*/
+func (e *harEntry) finish(timestamp *cdp.MonotonicTime) {
+	if timestamp != nil && !e.started.IsZero() {
+		e.Time = float64(timestamp.Time().Sub(e.started)) / float64(time.Millisecond)
+	}
+
+	e.Timings = harTimings{Blocked: -1, Dns: -1, Connect: -1, Ssl: -1}
+
+	t := e.timing
+	if t == nil {
+		e.Timings.Receive = e.Time
+		return
+	}
+
+	switch {
+	case t.DNSStart >= 0:
+		e.Timings.Blocked = t.DNSStart
+	case t.ConnectStart >= 0:
+		e.Timings.Blocked = t.ConnectStart
+	default:
+		e.Timings.Blocked = t.SendStart
+	}
+
+	e.Timings.Dns = harDuration(t.DNSStart, t.DNSEnd)
+	e.Timings.Connect = harDuration(t.ConnectStart, t.ConnectEnd)
+	e.Timings.Ssl = harDuration(t.SslStart, t.SslEnd)
+	e.Timings.Send = t.SendEnd - t.SendStart
+	e.Timings.Wait = t.ReceiveHeadersEnd - t.SendEnd
+
+	// the ssl handshake is part of the connect phase so it is not counted twice
+	spent := e.Timings.Blocked + e.Timings.Send + e.Timings.Wait
+	for _, phase := range []float64{e.Timings.Dns, e.Timings.Connect} {
+		if phase > 0 {
+			spent += phase
+		}
+	}
+
+	e.Timings.Receive = e.Time - spent
+	if e.Timings.Receive < 0 {
+		e.Timings.Receive = 0
+	}
+}
+
+// setBody
+/*
+stores a response body, text content is kept as is while binary content is base64 encoded
+*/
+func (e *harEntry) setBody(body []byte) {
+	e.Response.Content.Size = int64(len(body))
+
+	if utf8.Valid(body) {
+		e.Response.Content.Text = string(body)
+		return
+	}
+
+	e.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+	e.Response.Content.Encoding = "base64"
+}
+
+// fetchResponseBody
+/*
+collects a response body from the tab of the context, it must be called outside the listener as it waits on the browser
+*/
+func fetchResponseBody(ctx context.Context, id network.RequestID) ([]byte, error) {
+	c := chromedp.FromContext(ctx)
+	return network.GetResponseBody(id).Do(cdp.WithExecutor(ctx, c.Target))
+}
+
+func (h *harRecorder) requestBody(ctx context.Context, entry *harEntry, id network.RequestID) {
+	h.wg.Add(1)
+
+	go func() {
+		defer h.wg.Done()
+
+		body, err := h.fetchBody(ctx, id)
+		if err != nil {
+			log.Printf("unable to collect the response body of %s: %v", entry.Request.Url, err)
+			return
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		entry.setBody(body)
+	}()
+}
+
+// listener
+/*
+returns the target listener of a tab
+*/
+func (h *harRecorder) listener(ctx context.Context) func(ev interface{}) {
+	return func(ev interface{}) {
+		h.listen(ctx, ev)
+	}
+}
+
+// listen
+/*
+handles an event of the tab the context belongs to, it must not block or call back into the browser
+*/
+func (h *harRecorder) listen(ctx context.Context, ev interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		// a redirect reuses the request id, the previous hop is complete once the next one starts
+		if previous, ok := h.pending[ev.RequestID]; ok && ev.RedirectResponse != nil {
+			previous.setResponse(ev.RedirectResponse)
+			previous.Response.RedirectUrl = ev.Request.URL
+			previous.finish(ev.Timestamp)
+		}
+
+		entry := newHarEntry(ev)
+		h.pending[ev.RequestID] = entry
+		h.entries = append(h.entries, entry)
+	case *network.EventResponseReceived:
+		if entry, ok := h.pending[ev.RequestID]; ok {
+			entry.setResponse(ev.Response)
+		}
+	case *network.EventDataReceived:
+		if entry, ok := h.pending[ev.RequestID]; ok {
+			entry.Response.Content.Size += ev.DataLength
+		}
+	case *network.EventLoadingFinished:
+		entry, ok := h.pending[ev.RequestID]
+		if !ok {
+			return
+		}
+
+		delete(h.pending, ev.RequestID)
+		entry.Response.TransferSize = ev.EncodedDataLength
+		entry.finish(ev.Timestamp)
+
+		if h.fetchBody != nil && entry.Response.Status != 204 && entry.Response.Status/100 != 3 {
+			h.requestBody(ctx, entry, ev.RequestID)
+		}
+	case *network.EventLoadingFailed:
+		entry, ok := h.pending[ev.RequestID]
+		if !ok {
+			return
+		}
+
+		delete(h.pending, ev.RequestID)
+		entry.Response.Error = ev.ErrorText
+		entry.finish(ev.Timestamp)
+	}
+}
+
+// har
+/*
+waits for outstanding response bodies and assembles the HAR log. Requests that never completed are left out
+*/
+func (h *harRecorder) har() harFile {
+	h.wg.Wait()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	incomplete := map[*harEntry]bool{}
+	for _, entry := range h.pending {
+		incomplete[entry] = true
+	}
+
+	entries := make([]*harEntry, 0, len(h.entries))
+	for _, entry := range h.entries {
+		if !incomplete[entry] {
+			entries = append(entries, entry)
+		}
+	}
+
+	return harFile{
+		Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: "agent-workbench", Version: "0.0.0"},
+			Entries: entries,
+		},
+	}
+}
+
+// appendHar
+/*
+puts the entries saved at pth by earlier operations of the session ahead of the entries of this operation, so every
+operation's traffic stays in the one file
+*/
+func appendHar(pth string, file harFile) (harFile, error) {
+	byteSlice, err := os.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return file, nil
+	} else if err != nil {
+		return file, err
+	}
+
+	var previous harFile
+	if err = json.Unmarshal(byteSlice, &previous); err != nil {
+		return file, fmt.Errorf("unable to parse %s: %v", pth, err)
+	}
+
+	file.Log.Entries = append(previous.Log.Entries, file.Log.Entries...)
+
+	return file, nil
+}
@@ -0,0 +1,165 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func monotonic(seconds float64) *cdp.MonotonicTime {
+	t := cdp.MonotonicTime(time.Unix(0, 0).Add(time.Duration(seconds * float64(time.Second))))
+	return &t
+}
+
+func TestHarRecorder(t *testing.T) {
+	ctx := context.Background()
+	bodies := map[network.RequestID][]byte{
+		"2": {0xff, 0x00, 0xfe},
+	}
+
+	recorder := newHarRecorder(func(ctx context.Context, id network.RequestID) ([]byte, error) {
+		return bodies[id], nil
+	})
+
+	recorder.listen(ctx, &network.EventRequestWillBeSent{
+		RequestID: "1",
+		Request: &network.Request{
+			URL:     "http://bench-ai.com/?b=2&a=1",
+			Method:  "GET",
+			Headers: network.Headers{"User-Agent": "test"},
+		},
+		Timestamp: monotonic(1),
+	})
+	recorder.listen(ctx, &network.EventRequestWillBeSent{
+		RequestID: "1",
+		Request:   &network.Request{URL: "https://bench-ai.com/", Method: "GET"},
+		Timestamp: monotonic(1.2),
+		RedirectResponse: &network.Response{
+			Status:  301,
+			Headers: network.Headers{"Location": "https://bench-ai.com/"},
+		},
+	})
+	recorder.listen(ctx, &network.EventResponseReceived{
+		RequestID: "1",
+		Response: &network.Response{
+			Status:   200,
+			MimeType: "text/html",
+			Protocol: "h2",
+			Timing: &network.ResourceTiming{
+				DNSStart: -1, DNSEnd: -1, ConnectStart: -1, ConnectEnd: -1, SslStart: -1, SslEnd: -1,
+				SendStart: 1, SendEnd: 2, ReceiveHeadersEnd: 50,
+			},
+		},
+	})
+	recorder.listen(ctx, &network.EventLoadingFinished{RequestID: "1", Timestamp: monotonic(1.3), EncodedDataLength: 512})
+	recorder.listen(ctx, &network.EventRequestWillBeSent{
+		RequestID: "2",
+		Request: &network.Request{
+			URL:             "https://bench-ai.com/upload",
+			Method:          "POST",
+			Headers:         network.Headers{"Content-Type": "text/plain"},
+			HasPostData:     true,
+			PostDataEntries: []*network.PostDataEntry{{Bytes: base64.StdEncoding.EncodeToString([]byte("hello"))}},
+		},
+	})
+	recorder.listen(ctx, &network.EventResponseReceived{RequestID: "2", Response: &network.Response{Status: 200}})
+	recorder.listen(ctx, &network.EventLoadingFinished{RequestID: "2"})
+	recorder.listen(ctx, &network.EventRequestWillBeSent{
+		RequestID: "3",
+		Request:   &network.Request{URL: "https://bench-ai.com/never-finished.js"},
+	})
+
+	har := recorder.har()
+
+	if har.Log.Version != "1.2" {
+		t.Errorf("expected har version 1.2, found %s", har.Log.Version)
+	}
+
+	entries := har.Log.Entries
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, found %d", len(entries))
+	}
+
+	redirect := entries[0]
+	if redirect.Response.Status != 301 || redirect.Response.RedirectUrl != "https://bench-ai.com/" {
+		t.Errorf("redirect hop recorded incorrectly: %v", redirect.Response)
+	}
+
+	if len(redirect.Request.QueryString) != 2 || redirect.Request.QueryString[0].Name != "a" {
+		t.Errorf("query string parsed incorrectly: %v", redirect.Request.QueryString)
+	}
+
+	if redirect.Time < 199 || redirect.Time > 201 {
+		t.Errorf("expected the redirect to take 200ms, found %f", redirect.Time)
+	}
+
+	page := entries[1]
+	if page.Response.HttpVersion != "HTTP/2" || page.Response.TransferSize != 512 {
+		t.Errorf("response recorded incorrectly: %v", page.Response)
+	}
+
+	if page.Timings.Dns != -1 || page.Timings.Blocked != 1 || page.Timings.Wait != 48 {
+		t.Errorf("timings calculated incorrectly: %v", page.Timings)
+	}
+
+	upload := entries[2]
+	if upload.Request.PostData == nil || upload.Request.PostData.Text != "hello" || upload.Request.BodySize != 5 {
+		t.Errorf("post data recorded incorrectly: %v", upload.Request.PostData)
+	}
+
+	if upload.Response.Content.Encoding != "base64" || upload.Response.Content.Size != 3 {
+		t.Errorf("binary body recorded incorrectly: %v", upload.Response.Content)
+	}
+}
+
+func TestAppendHar(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "network.har")
+
+	operation := func(url string) harFile {
+		recorder := newHarRecorder(nil)
+		recorder.listen(context.Background(), &network.EventRequestWillBeSent{
+			RequestID: "1",
+			Request:   &network.Request{URL: url, Method: "GET"},
+		})
+		recorder.listen(context.Background(), &network.EventResponseReceived{RequestID: "1", Response: &network.Response{Status: 200}})
+		recorder.listen(context.Background(), &network.EventLoadingFinished{RequestID: "1"})
+		return recorder.har()
+	}
+
+	for _, url := range []string{"https://bench-ai.com/first", "https://bench-ai.com/second"} {
+		file, err := appendHar(pth, operation(url))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		byteSlice, err := json.Marshal(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = os.WriteFile(pth, byteSlice, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	byteSlice, err := os.ReadFile(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var file harFile
+	if err = json.Unmarshal(byteSlice, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := file.Log.Entries
+	if len(entries) != 2 || entries[0].Request.Url != "https://bench-ai.com/first" {
+		t.Errorf("the traffic of the first operation was not kept: %v", entries)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 	"image"
 	"image/jpeg"
@@ -254,16 +255,39 @@ func writeImg(
 
 // writeHtml
 /*
-saves the current html for writing
+saves the current html of the whole document for writing
 */
-func writeHtml(ctx context.Context) (error, string) {
+func writeHtml(snapshot string, ctx context.Context) (error, *htmlMetaData) {
 	var html string
-	err := chromedp.OuterHTML("body", &html).Do(ctx)
+	err := chromedp.OuterHTML("html", &html, chromedp.ByQuery).Do(ctx)
 	if err != nil {
-		return err, ""
+		return err, nil
+	}
+
+	return nil, &htmlMetaData{
+		snapShotName: snapshot,
+		fileName:     "page.html",
+		html:         &html,
+	}
+}
+
+// writeMhtml
+/*
+saves the current page as a self-contained mhtml archive for writing
+*/
+func writeMhtml(snapshot string, ctx context.Context) (error, *fileMetaData) {
+	data, err := page.CaptureSnapshot().WithFormat(page.CaptureSnapshotFormatMhtml).Do(ctx)
+	if err != nil {
+		return err, nil
 	}
 
-	return nil, html
+	byteSlice := []byte(data)
+
+	return nil, &fileMetaData{
+		snapShotName: snapshot,
+		fileName:     "page.mhtml",
+		byteData:     &byteSlice,
+	}
 }
 
 // saveSnapshot
@@ -271,11 +295,12 @@ func writeHtml(ctx context.Context) (error, string) {
 saves a snapshot with all the wanted files
 */
 func saveSnapshot(
-	htmlMap map[string]*string,
+	htmlSlice *[]*htmlMetaData,
 	saveNode map[string]*[]*nodeWithStyles,
 	nodeSlice []*nodeWithStyles,
 	fullPageImgSlice *[]*imageMetaData,
 	imgMD *imageMetaData,
+	mhtmlSlice *[]*fileMetaData,
 	ctx context.Context,
 	snapshot string) error {
 
@@ -283,12 +308,20 @@ func saveSnapshot(
 		*fullPageImgSlice = append(*fullPageImgSlice, imgMD)
 	}
 
-	if htmlMap != nil {
-		err, html := writeHtml(ctx)
+	if mhtmlSlice != nil {
+		err, fileMD := writeMhtml(snapshot, ctx)
+		if err != nil {
+			return err
+		}
+		*mhtmlSlice = append(*mhtmlSlice, fileMD)
+	}
+
+	if htmlSlice != nil {
+		err, htmlMD := writeHtml(snapshot, ctx)
 		if err != nil {
 			return err
 		}
-		htmlMap[snapshot] = &html
+		*htmlSlice = append(*htmlSlice, htmlMD)
 	}
 
 	if saveNode != nil {
@@ -308,7 +341,7 @@ TODO In order of priority
 
 // htmlIteratorAction
 /*
-collects all unique transition snapshots
+collects all unique transition snapshots, recordSnapshot is called with the name of every snapshot saved
 */
 func htmlIteratorAction(
 	iterLimit uint16,
@@ -316,9 +349,11 @@ func htmlIteratorAction(
 	startingSnapshot uint8,
 	snapshotName string,
 	imageQuality uint8,
-	htmlMap map[string]*string,
+	htmlSlice *[]*htmlMetaData,
 	saveNode map[string]*[]*nodeWithStyles,
 	fullPageImgSlice *[]*imageMetaData,
+	mhtmlSlice *[]*fileMetaData,
+	recordSnapshot func(snapshot string),
 ) chromedp.Tasks {
 
 	return chromedp.Tasks{
@@ -339,21 +374,23 @@ func htmlIteratorAction(
 			snapshot := fmt.Sprintf(
 				"%s_%d_%d_ms", snapshotName, startingSnapshot, diff.Milliseconds(),
 			)
+			recordSnapshot(snapshot)
 			err, imgMD := writeImg(snapshot, imageQuality, c)
 			pByteCollection = append(pByteCollection, imgMD.byteData)
 			if err != nil {
 				return err
 			}
-			err = populatedNodeAction("body", true, true, &nodeSlice).Do(c)
+			err = populatedNodeAction("body", true, true, true, false, &nodeSlice, chromedp.BySearch).Do(c)
 			if err != nil {
 				return err
 			}
 			err = saveSnapshot(
-				htmlMap,
+				htmlSlice,
 				saveNode,
 				nodeSlice,
 				fullPageImgSlice,
 				imgMD,
+				mhtmlSlice,
 				c,
 				snapshot)
 			if err != nil {
@@ -377,13 +414,14 @@ func htmlIteratorAction(
 				snapshot = fmt.Sprintf(
 					"%s_%d_%d_ms", snapshotName, startingSnapshot, diff.Milliseconds(),
 				)
+				recordSnapshot(snapshot)
 				err, imgMD = writeImg(snapshot, imageQuality, c)
 				if err != nil {
 					return err
 				}
 				pByteCollection = append(pByteCollection, imgMD.byteData)
 				currentNodeSlice := make([]*nodeWithStyles, 0, 10)
-				err = populatedNodeAction("body", true, true, &currentNodeSlice).Do(c)
+				err = populatedNodeAction("body", true, true, true, false, &currentNodeSlice, chromedp.BySearch).Do(c)
 				if err != nil {
 					return err
 				}
@@ -401,11 +439,12 @@ func htmlIteratorAction(
 					hitCount = 0
 
 					err = saveSnapshot(
-						htmlMap,
+						htmlSlice,
 						saveNode,
 						currentNodeSlice,
 						fullPageImgSlice,
 						imgMD,
+						mhtmlSlice,
 						c,
 						snapshot)
 
@@ -0,0 +1,254 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// NetworkRule
+/*
+A rule applied to every request whose url matches UrlPattern. Patterns use * for any run of characters and ? for a
+single character. The action is one of:
+
+block: the request fails as if blocked by the client
+
+mock: the request is answered with Status (default 200), Headers and either Body or the contents of File
+
+rewrite: the request is sent with Headers added, or overriding the headers of the same name
+*/
+type NetworkRule struct {
+	UrlPattern string            `json:"url_pattern"`
+	Action     string            `json:"action"`
+	Status     int64             `json:"status"`
+	Body       *string           `json:"body"`
+	File       *string           `json:"file"`
+	Headers    map[string]string `json:"headers"`
+}
+
+type networkRule struct {
+	NetworkRule
+	pattern *regexp.Regexp
+	body    []byte
+}
+
+// globToRegex
+/*
+converts a url pattern into an anchored regular expression, a backslash escapes the next character
+*/
+func globToRegex(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '*':
+			sb.WriteString(".*")
+		case r == '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	sb.WriteString("$")
+
+	return regexp.Compile(sb.String())
+}
+
+// compileNetworkRules
+/*
+validates the rules and loads the bodies of mocked responses so a bad rule fails before the browser starts
+*/
+func compileNetworkRules(rules []NetworkRule) ([]*networkRule, error) {
+	compiled := make([]*networkRule, 0, len(rules))
+
+	for i, rule := range rules {
+		if rule.UrlPattern == "" {
+			return nil, fmt.Errorf("network rule %d is missing a url_pattern", i)
+		}
+
+		pattern, err := globToRegex(rule.UrlPattern)
+		if err != nil {
+			return nil, fmt.Errorf("network rule %d has an invalid url_pattern: %v", i, err)
+		}
+
+		nr := &networkRule{NetworkRule: rule, pattern: pattern}
+
+		switch rule.Action {
+		case "block":
+		case "mock":
+			if rule.Body != nil && rule.File != nil {
+				return nil, fmt.Errorf("network rule %d can only have a body or a file", i)
+			}
+
+			if rule.File != nil {
+				if nr.body, err = os.ReadFile(*rule.File); err != nil {
+					return nil, fmt.Errorf("network rule %d is unable to read file: %v", i, err)
+				}
+
+				if headerValue(toNetworkHeaders(rule.Headers), "Content-Type") == "" {
+					if contentType := mime.TypeByExtension(filepath.Ext(*rule.File)); contentType != "" {
+						nr.Headers = mergeHeaders(rule.Headers, map[string]string{"Content-Type": contentType})
+					}
+				}
+			} else if rule.Body != nil {
+				nr.body = []byte(*rule.Body)
+			}
+
+			if nr.Status == 0 {
+				nr.Status = 200
+			}
+
+			if nr.Status < 100 || nr.Status > 599 {
+				return nil, fmt.Errorf("network rule %d has an invalid status: %d", i, nr.Status)
+			}
+		case "rewrite":
+			if len(rule.Headers) == 0 {
+				return nil, fmt.Errorf("network rule %d rewrites no headers", i)
+			}
+		default:
+			return nil, fmt.Errorf("network rule %d has an invalid action: %s", i, rule.Action)
+		}
+
+		compiled = append(compiled, nr)
+	}
+
+	return compiled, nil
+}
+
+func toNetworkHeaders(headers map[string]string) network.Headers {
+	converted := network.Headers{}
+	for name, value := range headers {
+		converted[name] = value
+	}
+
+	return converted
+}
+
+// mergeHeaders
+/*
+adds the overrides to the headers, an override replaces any header of the same name regardless of case
+*/
+func mergeHeaders(headers, overrides map[string]string) map[string]string {
+	merged := map[string]string{}
+
+	for name, value := range headers {
+		replaced := false
+		for override := range overrides {
+			if strings.EqualFold(name, override) {
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			merged[name] = value
+		}
+	}
+
+	for name, value := range overrides {
+		merged[name] = value
+	}
+
+	return merged
+}
+
+func headerEntries(headers map[string]string) []*fetch.HeaderEntry {
+	entries := make([]*fetch.HeaderEntry, 0, len(headers))
+	for name, value := range headers {
+		entries = append(entries, &fetch.HeaderEntry{Name: name, Value: value})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
+
+// matchNetworkRule
+/*
+the first rule matching the url wins, nil if the request should pass through untouched
+*/
+func matchNetworkRule(rules []*networkRule, url string) *networkRule {
+	for _, rule := range rules {
+		if rule.pattern.MatchString(url) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// interceptionAction
+/*
+decides how a paused request continues
+*/
+func interceptionAction(rules []*networkRule, ev *fetch.EventRequestPaused) chromedp.ActionFunc {
+	url := ev.Request.URL + ev.Request.URLFragment
+	rule := matchNetworkRule(rules, url)
+
+	return func(ctx context.Context) error {
+		if rule == nil {
+			return fetch.ContinueRequest(ev.RequestID).Do(ctx)
+		}
+
+		switch rule.Action {
+		case "block":
+			return fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+		case "mock":
+			return fetch.FulfillRequest(ev.RequestID, rule.Status).
+				WithResponseHeaders(headerEntries(rule.Headers)).
+				WithBody(base64.StdEncoding.EncodeToString(rule.body)).
+				Do(ctx)
+		default:
+			headers := map[string]string{}
+			for name, value := range ev.Request.Headers {
+				headers[name] = fmt.Sprint(value)
+			}
+
+			return fetch.ContinueRequest(ev.RequestID).
+				WithHeaders(headerEntries(mergeHeaders(headers, rule.Headers))).
+				Do(ctx)
+		}
+	}
+}
+
+// interceptRequests
+/*
+returns the target listener that applies the rules. Paused requests are answered from a goroutine, a listener must
+not call back into the browser
+*/
+func interceptRequests(ctx context.Context, rules []*networkRule) func(ev interface{}) {
+	return func(ev interface{}) {
+		paused, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+
+		go func() {
+			c := chromedp.FromContext(ctx)
+			if err := interceptionAction(rules, paused).Do(cdp.WithExecutor(ctx, c.Target)); err != nil {
+				log.Printf("unable to handle intercepted request %s: %v", paused.Request.URL, err)
+			}
+		}()
+	}
+}
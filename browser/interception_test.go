@@ -0,0 +1,95 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGlobToRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		url     string
+		match   bool
+	}{
+		{"*google-analytics.com*", "https://www.google-analytics.com/collect?v=1", true},
+		{"https://bench-ai.com/*", "https://bench-ai.com/about", true},
+		{"https://bench-ai.com/*", "http://bench-ai.com/about", false},
+		{"https://bench-ai.com/page?", "https://bench-ai.com/page1", true},
+		{"https://bench-ai.com/page\\?", "https://bench-ai.com/page1", false},
+		{"https://bench-ai.com/page\\?", "https://bench-ai.com/page?", true},
+		{"*.png", "https://bench-ai.com/logo.png.js", false},
+	}
+
+	for _, test := range tests {
+		re, err := globToRegex(test.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if re.MatchString(test.url) != test.match {
+			t.Errorf("expected pattern %s matching %s to be %v", test.pattern, test.url, test.match)
+		}
+	}
+}
+
+func TestCompileNetworkRules(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "user.json")
+	if err := os.WriteFile(pth, []byte(`{"name": "test"}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	body := "blocked"
+
+	rules, err := compileNetworkRules([]NetworkRule{
+		{UrlPattern: "*ads*", Action: "block"},
+		{UrlPattern: "*/api/user", Action: "mock", File: &pth},
+		{UrlPattern: "*", Action: "rewrite", Headers: map[string]string{"authorization": "token"}},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rule := matchNetworkRule(rules, "https://ads.example.com/banner.js"); rule == nil || rule.Action != "block" {
+		t.Errorf("expected the ad request to be blocked")
+	}
+
+	mock := matchNetworkRule(rules, "https://bench-ai.com/api/user")
+	if mock == nil || mock.Action != "mock" {
+		t.Fatalf("expected the api request to be mocked")
+	}
+
+	if mock.Status != 200 || string(mock.body) != `{"name": "test"}` || mock.Headers["Content-Type"] != "application/json" {
+		t.Errorf("mocked response loaded incorrectly: %d %s %v", mock.Status, mock.body, mock.Headers)
+	}
+
+	if rule := matchNetworkRule(rules, "https://bench-ai.com/"); rule == nil || rule.Action != "rewrite" {
+		t.Errorf("expected the page request to be rewritten")
+	}
+
+	invalid := [][]NetworkRule{
+		{{Action: "block"}},
+		{{UrlPattern: "*", Action: "drop"}},
+		{{UrlPattern: "*", Action: "rewrite"}},
+		{{UrlPattern: "*", Action: "mock", Status: 42}},
+		{{UrlPattern: "*", Action: "mock", Body: &body, File: &pth}},
+	}
+
+	for _, rules := range invalid {
+		if _, err = compileNetworkRules(rules); err == nil {
+			t.Errorf("expected rules %v to be invalid", rules)
+		}
+	}
+}
+
+func TestMergeHeaders(t *testing.T) {
+	merged := mergeHeaders(
+		map[string]string{"Accept": "*/*", "user-agent": "chrome"},
+		map[string]string{"User-Agent": "agent-workbench"},
+	)
+
+	if len(merged) != 2 || merged["User-Agent"] != "agent-workbench" || merged["Accept"] != "*/*" {
+		t.Errorf("headers merged incorrectly: %v", merged)
+	}
+}
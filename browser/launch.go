@@ -0,0 +1,143 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chromedp/chromedp"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type WindowSize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// flagValue
+/*
+chrome flags are either switches or take a string, numbers from json are passed on as strings
+*/
+func flagValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case bool, string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// execAllocatorOptions
+/*
+builds the options chrome is launched with, headless or not
+*/
+func execAllocatorOptions(options Options) ([]chromedp.ExecAllocatorOption, error) {
+	allocatorOptions := append([]chromedp.ExecAllocatorOption{}, chromedp.DefaultExecAllocatorOptions[:]...)
+
+	if !options.Headless {
+		allocatorOptions = append(allocatorOptions, chromedp.Flag("headless", false))
+	}
+
+	if options.ExecPath != "" {
+		allocatorOptions = append(allocatorOptions, chromedp.ExecPath(options.ExecPath))
+	}
+
+	if options.UserDataDir != "" {
+		allocatorOptions = append(allocatorOptions, chromedp.UserDataDir(options.UserDataDir))
+	}
+
+	if options.ProxyServer != "" {
+		allocatorOptions = append(allocatorOptions, chromedp.ProxyServer(options.ProxyServer))
+	}
+
+	if options.IgnoreCertificateErrors {
+		allocatorOptions = append(allocatorOptions, chromedp.IgnoreCertErrors)
+	}
+
+	if size := options.WindowSize; size != nil {
+		if size.Width <= 0 || size.Height <= 0 {
+			return nil, errors.New("window_size width and height must be positive")
+		}
+
+		allocatorOptions = append(allocatorOptions, chromedp.WindowSize(size.Width, size.Height))
+	}
+
+	// extra flags come last so they can override any of the flags above
+	for name, value := range options.ExtraFlags {
+		name = strings.TrimPrefix(name, "--")
+		if name == "" {
+			return nil, errors.New("extra_flags cannot contain an empty flag")
+		}
+
+		v, err := flagValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("extra flag %s has an %v", name, err)
+		}
+
+		allocatorOptions = append(allocatorOptions, chromedp.Flag(name, v))
+	}
+
+	return allocatorOptions, nil
+}
+
+// validateRemoteDebuggingUrl
+/*
+accepts the websocket url of a browser or the http address of its debugging port, the websocket url is then looked
+up through /json/version
+*/
+func validateRemoteDebuggingUrl(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return err
+	}
+
+	switch u.Scheme {
+	case "ws", "wss", "http", "https":
+	default:
+		return fmt.Errorf("remote_debugging_url scheme %s not supported", u.Scheme)
+	}
+
+	if u.Hostname() == "" {
+		return errors.New("remote_debugging_url must have a host")
+	}
+
+	// without the browser path the port is needed to find /json/version
+	if !strings.Contains(u.Path, "/devtools/browser/") && u.Port() == "" {
+		return errors.New("remote_debugging_url must have a port, e.g. http://localhost:9222")
+	}
+
+	return nil
+}
+
+// newAllocator
+/*
+connects to the browser at the remote debugging url, or launches a local one when there is none
+*/
+func newAllocator(options Options) (context.Context, context.CancelFunc, error) {
+	if options.RemoteDebuggingUrl != "" {
+		if options.ExecPath != "" || options.UserDataDir != "" || options.ProxyServer != "" ||
+			options.IgnoreCertificateErrors || options.WindowSize != nil || len(options.ExtraFlags) > 0 {
+			return nil, nil, errors.New("launch settings cannot be used with a remote browser")
+		}
+
+		if err := validateRemoteDebuggingUrl(options.RemoteDebuggingUrl); err != nil {
+			return nil, nil, err
+		}
+
+		ctx, cancel := chromedp.NewRemoteAllocator(context.Background(), options.RemoteDebuggingUrl)
+		return ctx, cancel, nil
+	}
+
+	allocatorOptions, err := execAllocatorOptions(options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := chromedp.NewExecAllocator(context.Background(), allocatorOptions...)
+	return ctx, cancel, nil
+}
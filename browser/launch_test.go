@@ -0,0 +1,97 @@
+package browser
+
+import (
+	"github.com/chromedp/chromedp"
+	"testing"
+)
+
+func TestExecAllocatorOptions(t *testing.T) {
+	defaults := len(chromedp.DefaultExecAllocatorOptions)
+
+	opts, err := execAllocatorOptions(Options{Headless: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(opts) != defaults {
+		t.Errorf("expected only the default options when headless, found %d", len(opts))
+	}
+
+	opts, err = execAllocatorOptions(Options{
+		UserDataDir:             "/tmp/profile",
+		ProxyServer:             "http://localhost:8080",
+		IgnoreCertificateErrors: true,
+		WindowSize:              &WindowSize{Width: 1280, Height: 800},
+		ExtraFlags:              map[string]interface{}{"--disable-gpu": true, "lang": "en-US"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// headless=false, 4 launch settings and 2 extra flags
+	if len(opts) != defaults+7 {
+		t.Errorf("expected %d options, found %d", defaults+7, len(opts))
+	}
+
+	invalid := []Options{
+		{WindowSize: &WindowSize{Width: 1280}},
+		{ExtraFlags: map[string]interface{}{"--": true}},
+		{ExtraFlags: map[string]interface{}{"lang": []string{"en"}}},
+	}
+
+	for _, options := range invalid {
+		if _, err = execAllocatorOptions(options); err == nil {
+			t.Errorf("expected launch settings %+v to be invalid", options)
+		}
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	if v, _ := flagValue(9222.0); v != "9222" {
+		t.Errorf("expected json numbers to become strings, found %v", v)
+	}
+
+	if v, _ := flagValue(false); v != false {
+		t.Errorf("expected switches to stay booleans, found %v", v)
+	}
+}
+
+func TestValidateRemoteDebuggingUrl(t *testing.T) {
+	valid := []string{
+		"ws://127.0.0.1:9222/devtools/browser/5f6c8e2a",
+		"wss://chrome.example.com/devtools/browser/5f6c8e2a",
+		"http://chrome:9222",
+		"ws://localhost:9222/",
+	}
+
+	for _, u := range valid {
+		if err := validateRemoteDebuggingUrl(u); err != nil {
+			t.Errorf("failed to detect valid remote debugging url %s: %v", u, err)
+		}
+	}
+
+	invalid := []string{
+		"chrome:9222",
+		"ftp://chrome:9222",
+		"http://chrome",
+		"ws://:9222/",
+	}
+
+	for _, u := range invalid {
+		if err := validateRemoteDebuggingUrl(u); err == nil {
+			t.Errorf("failed to detect invalid remote debugging url %s", u)
+		}
+	}
+
+	_, _, err := newAllocator(Options{RemoteDebuggingUrl: "http://chrome:9222", UserDataDir: "/tmp/profile"})
+	if err == nil {
+		t.Error("expected launch settings to be rejected for a remote browser")
+	}
+
+	_, cancel, err := newAllocator(Options{RemoteDebuggingUrl: "http://chrome:9222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+}
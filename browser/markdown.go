@@ -0,0 +1,457 @@
+package browser
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"regexp"
+	"strings"
+)
+
+var whitespaceRegex = regexp.MustCompile(`\s+`)
+var blankLineRegex = regexp.MustCompile(`\n{3,}`)
+var newlineRegex = regexp.MustCompile(`\n+`)
+
+// skippedTags are never rendered, they hold no readable content
+var skippedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Head:     true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+// boilerplateTags are dropped when only the main content of the page is wanted
+var boilerplateTags = map[atom.Atom]bool{
+	atom.Nav:    true,
+	atom.Aside:  true,
+	atom.Footer: true,
+	atom.Form:   true,
+}
+
+var blockTags = map[atom.Atom]bool{
+	atom.Html:       true,
+	atom.Body:       true,
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Main:       true,
+	atom.Header:     true,
+	atom.Footer:     true,
+	atom.Nav:        true,
+	atom.Aside:      true,
+	atom.Form:       true,
+	atom.Fieldset:   true,
+	atom.Figure:     true,
+	atom.Figcaption: true,
+	atom.Address:    true,
+	atom.Details:    true,
+	atom.Summary:    true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Dd:         true,
+}
+
+// getAttr
+/*
+returns the value of an attribute on a node, empty if it is not present
+*/
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isHidden
+/*
+checks whether an element is hidden from the reader through attributes or inline styles
+*/
+func isHidden(n *html.Node) bool {
+	if hasAttr(n, "hidden") || getAttr(n, "aria-hidden") == "true" {
+		return true
+	}
+
+	if n.DataAtom == atom.Input && strings.EqualFold(getAttr(n, "type"), "hidden") {
+		return true
+	}
+
+	style := strings.ToLower(strings.ReplaceAll(getAttr(n, "style"), " ", ""))
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// textContent
+/*
+collects the raw text of a node and its descendants
+*/
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && skippedTags[c.DataAtom] {
+			continue
+		}
+		sb.WriteString(textContent(c))
+	}
+
+	return sb.String()
+}
+
+// findElements
+/*
+returns all elements in the tree that satisfy the match
+*/
+func findElements(n *html.Node, match func(n *html.Node) bool) []*html.Node {
+	var found []*html.Node
+
+	if n.Type == html.ElementNode && match(n) {
+		found = append(found, n)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		found = append(found, findElements(c, match)...)
+	}
+
+	return found
+}
+
+// linkDensity
+/*
+the share of a node's text that is inside links, navigation blocks have a high density
+*/
+func linkDensity(n *html.Node) float64 {
+	textLength := len(strings.TrimSpace(textContent(n)))
+	if textLength == 0 {
+		return 0
+	}
+
+	linkLength := 0
+	for _, a := range findElements(n, func(n *html.Node) bool { return n.DataAtom == atom.A }) {
+		linkLength += len(strings.TrimSpace(textContent(a)))
+	}
+
+	return float64(linkLength) / float64(textLength)
+}
+
+// findMainContent
+/*
+a readability style pass that guesses which element holds the main content of the page. Explicit landmarks win,
+otherwise paragraphs award their text length to their parent and half to their grandparent, and the highest scoring
+element (penalized by its link density) is chosen
+*/
+func findMainContent(doc *html.Node) *html.Node {
+	landmarks := findElements(doc, func(n *html.Node) bool {
+		return (n.DataAtom == atom.Main || getAttr(n, "role") == "main") && !isHidden(n)
+	})
+
+	if len(landmarks) > 0 {
+		return landmarks[0]
+	}
+
+	articles := findElements(doc, func(n *html.Node) bool { return n.DataAtom == atom.Article })
+
+	if len(articles) == 1 {
+		return articles[0]
+	}
+
+	scores := map[*html.Node]float64{}
+	paragraphs := findElements(doc, func(n *html.Node) bool {
+		return n.DataAtom == atom.P || n.DataAtom == atom.Pre
+	})
+
+	for _, p := range paragraphs {
+		length := float64(len(strings.TrimSpace(textContent(p))))
+		if length < 25 {
+			continue
+		}
+
+		if parent := p.Parent; parent != nil {
+			scores[parent] += length
+
+			if grandParent := parent.Parent; grandParent != nil {
+				scores[grandParent] += length / 2
+			}
+		}
+	}
+
+	var best *html.Node
+	bestScore := 0.0
+
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if score > bestScore {
+			best = n
+			bestScore = score
+		}
+	}
+
+	if best != nil {
+		return best
+	}
+
+	if bodies := findElements(doc, func(n *html.Node) bool { return n.DataAtom == atom.Body }); len(bodies) > 0 {
+		return bodies[0]
+	}
+
+	return doc
+}
+
+type markdownConverter struct {
+	mainContent bool
+}
+
+// convertChildren
+/*
+converts every child of a node and joins the results
+*/
+func (m *markdownConverter) convertChildren(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(m.convert(c))
+	}
+
+	return sb.String()
+}
+
+// inline
+/*
+converts the children of a node and flattens them onto a single line
+*/
+func (m *markdownConverter) inline(n *html.Node) string {
+	return strings.TrimSpace(whitespaceRegex.ReplaceAllString(m.convertChildren(n), " "))
+}
+
+func block(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+
+	return "\n\n" + content + "\n\n"
+}
+
+// prefixLines
+/*
+prefixes the first line with first and every following non-empty line with rest
+*/
+func prefixLines(content, first, rest string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if i == 0 {
+			lines[i] = first + line
+		} else if line != "" {
+			lines[i] = rest + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *markdownConverter) convertList(n *html.Node) string {
+	var items []string
+	index := 1
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li || isHidden(c) {
+			continue
+		}
+
+		// list items are kept tight, nested blocks only get a single line break
+		content := strings.TrimSpace(newlineRegex.ReplaceAllString(m.convertChildren(c), "\n"))
+
+		if content == "" {
+			continue
+		}
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+
+	return block(strings.Join(items, "\n"))
+}
+
+func (m *markdownConverter) convertTable(n *html.Node) string {
+	rows := findElements(n, func(e *html.Node) bool { return e.DataAtom == atom.Tr })
+
+	var table [][]string
+	columns := 0
+
+	for _, row := range rows {
+		var cells []string
+		for c := row.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.DataAtom == atom.Th || c.DataAtom == atom.Td) {
+				cells = append(cells, strings.ReplaceAll(m.inline(c), "|", `\|`))
+			}
+		}
+
+		if len(cells) > columns {
+			columns = len(cells)
+		}
+
+		table = append(table, cells)
+	}
+
+	if columns == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	for i, cells := range table {
+		for len(cells) < columns {
+			cells = append(cells, "")
+		}
+
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+
+		if i == 0 {
+			sb.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+
+	return block(sb.String())
+}
+
+// convert
+/*
+converts a node and its descendants into markdown
+*/
+func (m *markdownConverter) convert(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return whitespaceRegex.ReplaceAllString(n.Data, " ")
+	case html.DocumentNode:
+		return m.convertChildren(n)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	if skippedTags[n.DataAtom] || isHidden(n) {
+		return ""
+	}
+
+	if m.mainContent && boilerplateTags[n.DataAtom] {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := m.inline(n)
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return block(strings.Repeat("#", level) + " " + text)
+	case atom.Br:
+		return "\n"
+	case atom.Hr:
+		return block("---")
+	case atom.Ul, atom.Ol:
+		return m.convertList(n)
+	case atom.Table:
+		return m.convertTable(n)
+	case atom.Pre:
+		return block("```\n" + strings.Trim(textContent(n), "\n") + "\n```")
+	case atom.Blockquote:
+		content := strings.TrimSpace(m.convertChildren(n))
+		if content == "" {
+			return ""
+		}
+		return block(prefixLines(content, "> ", "> "))
+	case atom.A:
+		text := m.inline(n)
+		href := getAttr(n, "href")
+		if text == "" {
+			return ""
+		}
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+			return text
+		}
+		return fmt.Sprintf("[%s](%s)", text, href)
+	case atom.Img:
+		alt := strings.TrimSpace(getAttr(n, "alt"))
+		if alt == "" {
+			return ""
+		}
+		return fmt.Sprintf("![%s](%s)", alt, getAttr(n, "src"))
+	case atom.Strong, atom.B:
+		if text := m.inline(n); text != "" {
+			return "**" + text + "**"
+		}
+		return ""
+	case atom.Em, atom.I:
+		if text := m.inline(n); text != "" {
+			return "*" + text + "*"
+		}
+		return ""
+	case atom.Code:
+		if text := strings.TrimSpace(textContent(n)); text != "" {
+			return "`" + text + "`"
+		}
+		return ""
+	}
+
+	if blockTags[n.DataAtom] {
+		return block(m.convertChildren(n))
+	}
+
+	return m.convertChildren(n)
+}
+
+// htmlToMarkdown
+/*
+converts an html document into markdown, dropping scripts, styles and hidden elements. When mainContent is set only
+the element guessed to hold the main content of the page is converted
+*/
+func htmlToMarkdown(htmlText string, mainContent bool) (string, error) {
+	doc, err := html.Parse(strings.NewReader(htmlText))
+	if err != nil {
+		return "", err
+	}
+
+	root := doc
+	if mainContent {
+		root = findMainContent(doc)
+	}
+
+	converter := markdownConverter{mainContent: mainContent}
+	markdown := converter.convert(root)
+
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+
+	markdown = blankLineRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+
+	return strings.TrimSpace(markdown) + "\n", nil
+}
@@ -0,0 +1,92 @@
+package browser
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPage = `<!DOCTYPE html>
+<html lang="en">
+<head><title>Bench</title><style>body { color: red; }</style></head>
+<body>
+	<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+	<main>
+		<h1>Bench   AI</h1>
+		<p>Accessibility <strong>made</strong> simple, see <a href="https://bench-ai.com">our site</a>.</p>
+		<script>console.log("hidden")</script>
+		<div hidden>secret</div>
+		<span style="display: none">also secret</span>
+		<ul>
+			<li>One</li>
+			<li>Two
+				<ol><li>Nested</li></ol>
+			</li>
+		</ul>
+		<table>
+			<tr><th>Name</th><th>Score</th></tr>
+			<tr><td>Page | A</td><td>90</td></tr>
+		</table>
+	</main>
+	<footer>Copyright</footer>
+</body>
+</html>`
+
+func TestHtmlToMarkdown(t *testing.T) {
+	markdown, err := htmlToMarkdown(testPage, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"# Bench AI",
+		"Accessibility **made** simple, see [our site](https://bench-ai.com).",
+		"- One\n- Two\n  1. Nested",
+		"| Name | Score |\n| --- | --- |\n| Page \\| A | 90 |",
+		"[Home](/)",
+		"Copyright",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(markdown, e) {
+			t.Errorf("markdown is missing %q, got:\n%s", e, markdown)
+		}
+	}
+
+	for _, hidden := range []string{"console.log", "color: red", "secret", "Bench\n"} {
+		if strings.Contains(markdown, hidden) {
+			t.Errorf("markdown contains hidden content %q", hidden)
+		}
+	}
+}
+
+func TestHtmlToMarkdownMainContent(t *testing.T) {
+	markdown, err := htmlToMarkdown(testPage, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(markdown, "# Bench AI") {
+		t.Errorf("main content did not start with the heading, got:\n%s", markdown)
+	}
+
+	if strings.Contains(markdown, "About") || strings.Contains(markdown, "Copyright") {
+		t.Error("main content kept navigation or footer")
+	}
+
+	scored := `<body>
+		<div class="links"><a href="/a">A long link to another page</a></div>
+		<div class="post">
+			<p>This is the first long paragraph of the article body.</p>
+			<p>This is the second long paragraph of the article body.</p>
+		</div>
+	</body>`
+
+	markdown, err = htmlToMarkdown(scored, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(markdown, "another page") || !strings.Contains(markdown, "second long paragraph") {
+		t.Errorf("scoring picked the wrong content, got:\n%s", markdown)
+	}
+}
@@ -0,0 +1,78 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/chromedp"
+	"time"
+)
+
+const documentHeightJs = `document.documentElement.scrollHeight`
+const scrollToBottomJs = `window.scrollTo(0, document.documentElement.scrollHeight)`
+
+// scrollUntilStableAction
+/*
+scrolls to the bottom of the page until the document height stops growing or the iteration limit is hit,
+optionally saving a snapshot after every scroll. recordSnapshot is called with the name of every snapshot saved
+*/
+func scrollUntilStableAction(
+	iterLimit uint16,
+	pauseTime uint32,
+	snapshotName string,
+	imageQuality uint8,
+	htmlSlice *[]*htmlMetaData,
+	fullPageImgSlice *[]*imageMetaData,
+	recordSnapshot func(snapshot string),
+) chromedp.Tasks {
+
+	return chromedp.Tasks{
+		chromedp.ActionFunc(func(c context.Context) error {
+
+			var height int64
+			if err := chromedp.Evaluate(documentHeightJs, &height).Do(c); err != nil {
+				return err
+			}
+
+			for count := uint16(0); count < iterLimit; count++ {
+				if err := chromedp.Evaluate(scrollToBottomJs, nil).Do(c); err != nil {
+					return err
+				}
+
+				if err := chromedp.Sleep(time.Duration(pauseTime) * time.Millisecond).Do(c); err != nil {
+					return err
+				}
+
+				if htmlSlice != nil || fullPageImgSlice != nil {
+					snapshot := fmt.Sprintf("%s_%d", snapshotName, count)
+					recordSnapshot(snapshot)
+
+					var imgMD *imageMetaData
+					if fullPageImgSlice != nil {
+						var err error
+						if err, imgMD = writeImg(snapshot, imageQuality, c); err != nil {
+							return err
+						}
+					}
+
+					if err := saveSnapshot(htmlSlice, nil, nil, fullPageImgSlice, imgMD, nil, c, snapshot); err != nil {
+						return err
+					}
+				}
+
+				var newHeight int64
+				if err := chromedp.Evaluate(documentHeightJs, &newHeight).Do(c); err != nil {
+					return err
+				}
+
+				// the page has stopped lazy loading content
+				if newHeight <= height {
+					return nil
+				}
+
+				height = newHeight
+			}
+
+			return nil
+		}),
+	}
+}
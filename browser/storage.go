@@ -0,0 +1,166 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"os"
+	"time"
+)
+
+type originStorage struct {
+	Origin         string            `json:"origin"`
+	LocalStorage   map[string]string `json:"local_storage"`
+	SessionStorage map[string]string `json:"session_storage"`
+}
+
+// storageState
+/*
+the cookies and web storage of a browser session, what a site needs to recognise a logged in user
+*/
+type storageState struct {
+	Cookies []*network.Cookie `json:"cookies"`
+	Origins []*originStorage  `json:"origins"`
+}
+
+type storageMetaData struct {
+	fileName string
+	state    *storageState
+}
+
+// readStorageJs returns the web storage of the current origin, null for pages without one such as about:blank
+const readStorageJs = `(() => {
+	if (location.origin === "null") {
+		return null;
+	}
+	const dump = (storage) => Object.fromEntries(Object.keys(storage).map((key) => [key, storage.getItem(key)]));
+	return {
+		origin: location.origin,
+		local_storage: dump(localStorage),
+		session_storage: dump(sessionStorage),
+	};
+})()`
+
+// writeStorageJs fills the web storage of the current origin from the json object it is formatted with
+const writeStorageJs = `((state) => {
+	for (const [key, value] of Object.entries(state.local_storage || {})) {
+		localStorage.setItem(key, value);
+	}
+	for (const [key, value] of Object.entries(state.session_storage || {})) {
+		sessionStorage.setItem(key, value);
+	}
+})(%s)`
+
+// toCookieParams
+/*
+converts saved cookies back into the parameters needed to set them, session cookies stay session cookies
+*/
+func toCookieParams(cookies []*network.Cookie) []*network.CookieParam {
+	params := make([]*network.CookieParam, 0, len(cookies))
+
+	for _, cookie := range cookies {
+		param := &network.CookieParam{
+			Name:         cookie.Name,
+			Value:        cookie.Value,
+			Domain:       cookie.Domain,
+			Path:         cookie.Path,
+			Secure:       cookie.Secure,
+			HTTPOnly:     cookie.HTTPOnly,
+			SameSite:     cookie.SameSite,
+			Priority:     cookie.Priority,
+			SourceScheme: cookie.SourceScheme,
+			SourcePort:   cookie.SourcePort,
+			PartitionKey: cookie.PartitionKey,
+		}
+
+		if !cookie.Session && cookie.Expires > 0 {
+			expires := cdp.TimeSinceEpoch(time.Unix(0, int64(cookie.Expires*float64(time.Second))))
+			param.Expires = &expires
+		}
+
+		params = append(params, param)
+	}
+
+	return params
+}
+
+func (s *storageState) findOrigin(origin string) *originStorage {
+	for _, o := range s.Origins {
+		if o.Origin == origin {
+			return o
+		}
+	}
+
+	return nil
+}
+
+// saveStorageAction
+/*
+collects the cookies of the current page and the web storage of its origin
+*/
+func saveStorageAction(state *storageState) chromedp.ActionFunc {
+	return func(c context.Context) error {
+		cookies, err := network.GetCookies().Do(c)
+		if err != nil {
+			return err
+		}
+
+		var origin *originStorage
+		if err = chromedp.Evaluate(readStorageJs, &origin).Do(c); err != nil {
+			return err
+		}
+
+		state.Cookies = cookies
+		state.Origins = make([]*originStorage, 0, 1)
+
+		if origin != nil {
+			state.Origins = append(state.Origins, origin)
+		}
+
+		return nil
+	}
+}
+
+// loadStorageAction
+/*
+sets the saved cookies and restores the web storage of the current origin if the file holds it
+*/
+func loadStorageAction(pth string) chromedp.ActionFunc {
+	return func(c context.Context) error {
+		byteSlice, err := os.ReadFile(pth)
+		if err != nil {
+			return err
+		}
+
+		var state storageState
+		if err = json.Unmarshal(byteSlice, &state); err != nil {
+			return fmt.Errorf("unable to parse storage state %s: %v", pth, err)
+		}
+
+		if len(state.Cookies) > 0 {
+			if err = network.SetCookies(toCookieParams(state.Cookies)).Do(c); err != nil {
+				return err
+			}
+		}
+
+		var currentOrigin string
+		if err = chromedp.Evaluate("location.origin", &currentOrigin).Do(c); err != nil {
+			return err
+		}
+
+		origin := state.findOrigin(currentOrigin)
+		if origin == nil {
+			return nil
+		}
+
+		originJson, err := json.Marshal(origin)
+		if err != nil {
+			return err
+		}
+
+		return chromedp.Evaluate(fmt.Sprintf(writeStorageJs, originJson), nil).Do(c)
+	}
+}
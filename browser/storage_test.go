@@ -0,0 +1,51 @@
+package browser
+
+import (
+	"encoding/json"
+	"github.com/chromedp/cdproto/network"
+	"testing"
+)
+
+func TestStorageState(t *testing.T) {
+	saved := []byte(`{
+		"cookies": [
+			{"name": "session", "value": "abc", "domain": "bench-ai.com", "path": "/", "expires": -1, "session": true},
+			{"name": "remember", "value": "1", "domain": ".bench-ai.com", "path": "/", "expires": 1900000000.5,
+			 "secure": true, "sameSite": "Lax"}
+		],
+		"origins": [
+			{"origin": "https://bench-ai.com", "local_storage": {"token": "xyz"}, "session_storage": {}}
+		]
+	}`)
+
+	var state storageState
+	if err := json.Unmarshal(saved, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	params := toCookieParams(state.Cookies)
+
+	if len(params) != 2 {
+		t.Fatalf("expected 2 cookies, found %d", len(params))
+	}
+
+	if params[0].Expires != nil {
+		t.Error("a session cookie should not be given an expiry")
+	}
+
+	if params[1].Expires == nil || params[1].Expires.Time().Unix() != 1900000000 {
+		t.Errorf("cookie expiry converted incorrectly: %v", params[1].Expires)
+	}
+
+	if !params[1].Secure || params[1].SameSite != network.CookieSameSiteLax {
+		t.Errorf("cookie attributes converted incorrectly: %+v", params[1])
+	}
+
+	if origin := state.findOrigin("https://bench-ai.com"); origin == nil || origin.LocalStorage["token"] != "xyz" {
+		t.Errorf("expected the saved origin to be found")
+	}
+
+	if origin := state.findOrigin("https://example.com"); origin != nil {
+		t.Errorf("expected an unknown origin not to be found")
+	}
+}
@@ -0,0 +1,478 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// tab
+/*
+a page of the browser, ctx is nil until tasks are first routed to a tab the page opened by itself. Every tab starts
+with the emulation of the settings, media and vision emulation are added to the tab active when they run
+*/
+type tab struct {
+	id        target.ID
+	name      string
+	ctx       context.Context
+	cancel    context.CancelFunc
+	emulation emulationState
+
+	// set for popups attached when chrome reports them, closed once the popup is set up
+	attached  chan struct{}
+	attachErr error
+}
+
+// settle
+/*
+waits for a popup attached when chrome reported it to be set up, returns the error of its setup
+*/
+func (t *tab) settle() error {
+	if t.attached == nil {
+		return nil
+	}
+
+	<-t.attached
+
+	return t.attachErr
+}
+
+type tabInfo struct {
+	Index  int    `json:"index"`
+	Name   string `json:"name,omitempty"`
+	Url    string `json:"url"`
+	Title  string `json:"title"`
+	Active bool   `json:"active"`
+}
+
+// TabSelector
+/*
+Picks a tab by exactly one of its index (in the order the tabs were opened), its name, a pattern matching its url or
+text in its title. An empty selector is the active tab
+*/
+type TabSelector struct {
+	Index      *int
+	Name       string
+	UrlPattern *regexp.Regexp
+	Title      string
+}
+
+// popupAttacher
+/*
+attaches to the popups of the tabs of the operation as soon as chrome reports them, so the browser settings apply to
+a popup before it is switched to. Pages opened by other clients of a shared browser are never attached
+*/
+type popupAttacher struct {
+	mu     sync.Mutex
+	known  map[target.ID]bool
+	popups map[target.ID]*tab
+}
+
+func (p *popupAttacher) init() {
+	if p.known == nil {
+		p.known = map[target.ID]bool{}
+		p.popups = map[target.ID]*tab{}
+	}
+}
+
+// track
+/*
+marks a tab opened by the tasks as one of the operation, popups it opens are attached
+*/
+func (p *popupAttacher) track(id target.ID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+	p.known[id] = true
+}
+
+// opened
+/*
+the tab of a new popup opened by a tab of the operation, nil for any other target
+*/
+func (p *popupAttacher) opened(info *target.Info, newTab func(id target.ID) *tab) *tab {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+
+	if info.Type != "page" || !p.known[info.OpenerID] || p.known[info.TargetID] {
+		return nil
+	}
+
+	t := newTab(info.TargetID)
+	t.attached = make(chan struct{})
+
+	p.known[info.TargetID] = true
+	p.popups[info.TargetID] = t
+
+	return t
+}
+
+// get
+/*
+the tab of a popup found by syncTabs, chrome may not have reported it yet
+*/
+func (p *popupAttacher) get(id target.ID, newTab func(id target.ID) *tab) *tab {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.init()
+
+	if t, ok := p.popups[id]; ok {
+		return t
+	}
+
+	t := newTab(id)
+
+	p.known[id] = true
+	p.popups[id] = t
+
+	return t
+}
+
+// popupListener
+/*
+chrome reports the opener of a popup in the event of its target, before the popup has loaded its page
+*/
+func (b *Executor) popupListener(ev interface{}) {
+	var info *target.Info
+
+	switch ev := ev.(type) {
+	case *target.EventTargetCreated:
+		info = ev.TargetInfo
+	case *target.EventTargetInfoChanged:
+		info = ev.TargetInfo
+	default:
+		return
+	}
+
+	if t := b.popups.opened(info, b.newTab); t != nil {
+		// attaching sends commands to the browser so it can not run on the event loop
+		go b.attachPopup(t)
+	}
+}
+
+func (b *Executor) attachPopup(t *tab) {
+	defer close(t.attached)
+
+	t.ctx, t.cancel = chromedp.NewContext(b.ctx, chromedp.WithTargetID(t.id))
+	b.listenTarget(t.ctx)
+
+	// the error is returned when the popup is switched to
+	t.attachErr = chromedp.Run(t.ctx, b.tabSetup)
+}
+
+// listenTarget
+/*
+registers the listeners of the browser settings on a tab
+*/
+func (b *Executor) listenTarget(ctx context.Context) {
+	for _, listener := range b.targetListeners {
+		chromedp.ListenTarget(ctx, listener(ctx))
+	}
+}
+
+// activeContext
+/*
+the context tasks are currently routed to, tasks that query elements inside a cross-origin frame are moved to its
+target by appendFrameTask
+*/
+func (b *Executor) activeContext() context.Context {
+	if b.activeTab == nil {
+		return b.ctx
+	}
+
+	return b.activeTab.ctx
+}
+
+func (b *Executor) newTab(id target.ID) *tab {
+	return &tab{id: id, emulation: b.emulation}
+}
+
+// initTabs
+/*
+registers the first tab once the browser has started
+*/
+func (b *Executor) initTabs() {
+	c := chromedp.FromContext(b.ctx)
+	if c == nil || c.Target == nil {
+		return
+	}
+
+	b.activeTab = b.newTab(c.Target.TargetID)
+	b.activeTab.ctx = b.ctx
+	b.tabs = []*tab{b.activeTab}
+
+	b.popups.track(b.activeTab.id)
+	chromedp.ListenBrowser(b.ctx, b.popupListener)
+}
+
+// syncTabs
+/*
+drops tabs that were closed by their page and adds popups opened by a known tab, so tabs of other clients of a shared
+browser are never picked up. Returns the current info of every page
+*/
+func (b *Executor) syncTabs(ctx context.Context) (map[target.ID]*target.Info, error) {
+	infos, err := chromedp.Targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := map[target.ID]*target.Info{}
+	for _, info := range infos {
+		if info.Type == "page" {
+			pages[info.TargetID] = info
+		}
+	}
+
+	known := map[target.ID]bool{}
+	open := make([]*tab, 0, len(b.tabs))
+
+	for _, t := range b.tabs {
+		if _, ok := pages[t.id]; ok {
+			open = append(open, t)
+			known[t.id] = true
+		}
+	}
+
+	// a popup can open another popup so keep looking until no new tab is found
+	for found := true; found; {
+		found = false
+		for _, info := range infos {
+			if info.Type == "page" && !known[info.TargetID] && known[info.OpenerID] {
+				open = append(open, b.popups.get(info.TargetID, b.newTab))
+				known[info.TargetID] = true
+				found = true
+			}
+		}
+	}
+
+	b.tabs = open
+
+	return pages, nil
+}
+
+// findTab
+/*
+returns the index of the tab matching the selector
+*/
+func (b *Executor) findTab(selector TabSelector, pages map[target.ID]*target.Info) (int, error) {
+	switch {
+	case selector.Index != nil:
+		if *selector.Index < 0 || *selector.Index >= len(b.tabs) {
+			return 0, fmt.Errorf("tab index %d is out of range, %d tabs are open", *selector.Index, len(b.tabs))
+		}
+		return *selector.Index, nil
+	case selector.Name != "":
+		for i, t := range b.tabs {
+			if t.name == selector.Name {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("no tab is named %s", selector.Name)
+	case selector.UrlPattern != nil:
+		for i, t := range b.tabs {
+			if info, ok := pages[t.id]; ok && selector.UrlPattern.MatchString(info.URL) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("no tab has a url matching %s", selector.UrlPattern.String())
+	case selector.Title != "":
+		for i, t := range b.tabs {
+			if info, ok := pages[t.id]; ok && strings.Contains(info.Title, selector.Title) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("no tab has a title containing %s", selector.Title)
+	}
+
+	for i, t := range b.tabs {
+		if t == b.activeTab {
+			return i, nil
+		}
+	}
+
+	return 0, errors.New("the active tab was closed")
+}
+
+// attachTab
+/*
+creates the context of a tab the page opened by itself, and sets it up like the first tab
+*/
+func (b *Executor) attachTab(t *tab) error {
+	if t.attached != nil {
+		return t.settle()
+	}
+
+	if t.ctx != nil {
+		return nil
+	}
+
+	t.ctx, t.cancel = chromedp.NewContext(b.ctx, chromedp.WithTargetID(t.id))
+	b.listenTarget(t.ctx)
+
+	return chromedp.Run(t.ctx, b.tabSetup)
+}
+
+// activateTab
+/*
+routes the following tasks to the tab and brings it to the front
+*/
+func (b *Executor) activateTab(t *tab) error {
+	if err := b.attachTab(t); err != nil {
+		return err
+	}
+
+	b.activeTab = t
+
+	return chromedp.Run(t.ctx, page.BringToFront())
+}
+
+func newTabAction(b *Executor, name, url string) chromedp.ActionFunc {
+	return func(c context.Context) error {
+		if _, err := b.syncTabs(c); err != nil {
+			return err
+		}
+
+		for _, t := range b.tabs {
+			if name != "" && t.name == name {
+				return fmt.Errorf("a tab is already named %s", name)
+			}
+		}
+
+		ctx, cancel := chromedp.NewContext(b.ctx)
+		b.listenTarget(ctx)
+
+		if err := chromedp.Run(ctx, b.tabSetup); err != nil {
+			cancel()
+			return err
+		}
+
+		t := b.newTab(chromedp.FromContext(ctx).Target.TargetID)
+		t.name, t.ctx, t.cancel = name, ctx, cancel
+
+		// tracked before the page loads so the popups it opens are attached
+		b.popups.track(t.id)
+
+		if url != "" {
+			if err := chromedp.Run(ctx, chromedp.Navigate(url)); err != nil {
+				cancel()
+				return err
+			}
+		}
+
+		b.tabs = append(b.tabs, t)
+
+		return b.activateTab(t)
+	}
+}
+
+func switchTabAction(b *Executor, selector TabSelector) chromedp.ActionFunc {
+	return func(c context.Context) error {
+		pages, err := b.syncTabs(c)
+		if err != nil {
+			return err
+		}
+
+		index, err := b.findTab(selector, pages)
+		if err != nil {
+			return err
+		}
+
+		return b.activateTab(b.tabs[index])
+	}
+}
+
+func listTabsAction(b *Executor, tabs *[]tabInfo) chromedp.ActionFunc {
+	return func(c context.Context) error {
+		pages, err := b.syncTabs(c)
+		if err != nil {
+			return err
+		}
+
+		for i, t := range b.tabs {
+			info := tabInfo{Index: i, Name: t.name, Active: t == b.activeTab}
+			if p, ok := pages[t.id]; ok {
+				info.Url, info.Title = p.URL, p.Title
+			}
+
+			*tabs = append(*tabs, info)
+		}
+
+		return nil
+	}
+}
+
+// closeTabAction
+/*
+closes a tab, when it is the active one the tasks move to the tab opened before it
+*/
+func closeTabAction(b *Executor, selector TabSelector) chromedp.ActionFunc {
+	return func(c context.Context) error {
+		pages, err := b.syncTabs(c)
+		if err != nil {
+			return err
+		}
+
+		index, err := b.findTab(selector, pages)
+		if err != nil {
+			return err
+		}
+
+		if len(b.tabs) == 1 {
+			return errors.New("the last tab cannot be closed")
+		}
+
+		closing := b.tabs[index]
+		b.tabs = append(b.tabs[:index], b.tabs[index+1:]...)
+
+		_ = closing.settle()
+
+		if closing.cancel != nil {
+			// cancelling the context of a tab closes it
+			closing.cancel()
+		} else {
+			// the first tab owns the browser, so only its page is closed
+			browserExecutor := cdp.WithExecutor(c, chromedp.FromContext(c).Browser)
+			if err = target.CloseTarget(closing.id).Do(browserExecutor); err != nil {
+				return err
+			}
+		}
+
+		if closing != b.activeTab {
+			return nil
+		}
+
+		if index > 0 {
+			index--
+		}
+
+		return b.activateTab(b.tabs[index])
+	}
+}
+
+// closeTabs
+/*
+closes the tabs opened during the operation, the first tab is closed with the browser
+*/
+func (b *Executor) closeTabs() {
+	for _, t := range b.tabs {
+		_ = t.settle()
+
+		if t.cancel != nil {
+			t.cancel()
+		}
+	}
+
+	b.tabs = nil
+	b.activeTab = nil
+}
@@ -0,0 +1,97 @@
+package browser
+
+import (
+	"github.com/chromedp/cdproto/target"
+	"regexp"
+	"testing"
+)
+
+func TestFindTab(t *testing.T) {
+	first := &tab{id: "1"}
+	docs := &tab{id: "2", name: "docs"}
+	popup := &tab{id: "3"}
+
+	b := Executor{tabs: []*tab{first, docs, popup}, activeTab: docs}
+
+	pages := map[target.ID]*target.Info{
+		"1": {TargetID: "1", URL: "https://bench-ai.com/", Title: "Bench AI"},
+		"2": {TargetID: "2", URL: "https://bench-ai.com/docs", Title: "Docs"},
+		"3": {TargetID: "3", URL: "https://accounts.example.com/login", Title: "Sign in"},
+	}
+
+	index := 2
+	outOfRange := 3
+
+	tests := []struct {
+		selector TabSelector
+		expected int
+	}{
+		{TabSelector{}, 1},
+		{TabSelector{Index: &index}, 2},
+		{TabSelector{Name: "docs"}, 1},
+		{TabSelector{UrlPattern: regexp.MustCompile(`accounts\.`)}, 2},
+		{TabSelector{Title: "Bench"}, 0},
+	}
+
+	for _, test := range tests {
+		found, err := b.findTab(test.selector, pages)
+		if err != nil {
+			t.Errorf("unable to find tab %+v: %v", test.selector, err)
+		} else if found != test.expected {
+			t.Errorf("expected tab %d for %+v, found %d", test.expected, test.selector, found)
+		}
+	}
+
+	failTable := []TabSelector{
+		{Index: &outOfRange},
+		{Name: "missing"},
+		{UrlPattern: regexp.MustCompile(`^ftp`)},
+		{Title: "Checkout"},
+	}
+
+	for _, selector := range failTable {
+		if _, err := b.findTab(selector, pages); err == nil {
+			t.Errorf("expected no tab to match %+v", selector)
+		}
+	}
+}
+
+func TestPopupAttacher(t *testing.T) {
+	b := Executor{}
+	b.popups.track("1")
+
+	popup := b.popups.opened(&target.Info{TargetID: "2", OpenerID: "1", Type: "page"}, b.newTab)
+	if popup == nil || popup.attached == nil {
+		t.Fatalf("a popup of a tab of the operation should be attached")
+	}
+
+	if b.popups.opened(&target.Info{TargetID: "2", OpenerID: "1", Type: "page"}, b.newTab) != nil {
+		t.Errorf("a popup should only be attached once")
+	}
+
+	if b.popups.opened(&target.Info{TargetID: "3", OpenerID: "2", Type: "page"}, b.newTab) == nil {
+		t.Errorf("a popup opened by a popup should be attached")
+	}
+
+	failTable := []*target.Info{
+		{TargetID: "4", OpenerID: "9", Type: "page"},
+		{TargetID: "5", Type: "page"},
+		{TargetID: "6", OpenerID: "1", Type: "iframe"},
+	}
+
+	for _, info := range failTable {
+		if b.popups.opened(info, b.newTab) != nil {
+			t.Errorf("expected target %+v not to be attached", info)
+		}
+	}
+
+	if b.popups.get("2", b.newTab) != popup {
+		t.Errorf("syncTabs should use the tab of a popup that is already attached")
+	}
+
+	// found by syncTabs before chrome reported it
+	late := b.popups.get("7", b.newTab)
+	if late.attached != nil || b.popups.opened(&target.Info{TargetID: "7", OpenerID: "1", Type: "page"}, b.newTab) != nil {
+		t.Errorf("a popup found by syncTabs should be attached when it is switched to")
+	}
+}
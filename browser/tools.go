@@ -1,14 +1,24 @@
 package browser
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 )
 
@@ -18,11 +28,16 @@ type nodeMetaData struct {
 	Xpath      string              `json:"xpath"`
 	Attributes map[string]string   `json:"attributes"`
 	CssStyles  []map[string]string `json:"css_styles,omitempty"`
+	BoxModel   *nodeBoxModel       `json:"box_model,omitempty"`
+	Visible    *bool               `json:"visible,omitempty"`
+	InViewport *bool               `json:"in_viewport,omitempty"`
+	Text       *string             `json:"text,omitempty"`
 }
 
 type nodeWithStyles struct {
 	cssStyles []*css.ComputedStyleProperty
 	node      *cdp.Node
+	geometry  *nodeGeometry
 }
 
 type imageMetaData struct {
@@ -31,52 +46,233 @@ type imageMetaData struct {
 	byteData     *[]byte
 }
 
+type htmlMetaData struct {
+	snapShotName string
+	fileName     string
+	html         *string
+}
+
+type markdownMetaData struct {
+	snapShotName string
+	sourceFile   string
+	html         *string
+	mainContent  bool
+}
+
+type auditMetaData struct {
+	ruleIds []string
+	nodes   *[]*nodeWithStyles
+}
+
+type fileMetaData struct {
+	snapShotName string
+	fileName     string
+	byteData     *[]byte
+}
+
+// PdfOptions
+/*
+Page layout used when printing a pdf, sizes are in inches. Zero values fall back to the browser defaults
+*/
+type PdfOptions struct {
+	Landscape       bool
+	PrintBackground bool
+	PaperWidth      float64
+	PaperHeight     float64
+	Margins         *[4]float64 // top, bottom, left, right
+	PageRanges      string
+}
+
+// Options
+/*
+The settings of a browser operation that configure the browser session rather than a single command
+*/
+type Options struct {
+	Headless     bool
+	Timeout      *int16
+	RecordEvents bool
+	RecordHar    bool
+	HarBodies    bool
+	NetworkRules []NetworkRule
+	Emulation    *Emulation
+	DialogPolicy *DialogPolicy
+
+	ExecPath                string
+	UserDataDir             string
+	ProxyServer             string
+	IgnoreCertificateErrors bool
+	WindowSize              *WindowSize
+	ExtraFlags              map[string]interface{}
+	RemoteDebuggingUrl      string
+	SaveDownloads           bool
+}
+
 type Executor struct {
-	Url         string
-	savePath    string
-	ctx         context.Context
-	cancel      context.CancelFunc
-	tasks       chromedp.Tasks
-	imageList   []*imageMetaData
-	htmlMap     map[string]*string
-	locationMap map[string][]*string
-	nodeMap     map[string]*[]*nodeWithStyles
+	Url          string
+	savePath     string
+	ctx          context.Context
+	cancel       context.CancelFunc
+	tasks        chromedp.Tasks
+	imageList    []*imageMetaData
+	fileList     []*fileMetaData
+	htmlList     []*htmlMetaData
+	locationMap  map[string][]*string
+	nodeMap      map[string]*[]*nodeWithStyles
+	evalMap      map[string]map[string]*[]byte
+	markdownList []*markdownMetaData
+	axTreeMap    map[string]*[]axNodeMetaData
+	auditMap     map[string]*auditMetaData
+	events       *eventRecorder
+	har          *harRecorder
+	emulation    emulationState
+	emulationMap map[string]emulationState
+	storageList  []*storageMetaData
+
+	// snapshots whose collected nodes include every descendant with its computed styles
+	styledSnapshots map[string]bool
+	targetListeners []func(ctx context.Context) func(ev interface{})
+	tabSetup        chromedp.Tasks
+	tabs            []*tab
+	activeTab       *tab
+	tabMap          map[string]*[]tabInfo
+	popups          popupAttacher
+	downloads       *downloadRecorder
+	dialogs         *dialogHandler
+	frameStack      []*frame
+	frames          []*frame
 }
 
-func (b *Executor) Init(headless bool, timeout *int16, sessionPath string) *Executor {
+func (b *Executor) Init(options Options, sessionPath string) *Executor {
 
 	b.savePath = sessionPath
 
 	log.Printf("writing session data too folder: %s \n", b.savePath)
 
-	if headless {
-		b.ctx, b.cancel = chromedp.NewContext(
-			context.Background(),
-		)
-	} else {
-		actx, _ := chromedp.NewExecAllocator(
-			context.Background(),
-			append(
-				chromedp.DefaultExecAllocatorOptions[:],
-				//chromedp.WindowSize(200, 200),
-				chromedp.Flag("headless", false))...)
+	actx, allocatorCancel, err := newAllocator(options)
+	if err != nil {
+		log.Fatalf("Invalid browser launch settings: %v", err)
+	}
+
+	ctx, cancel := chromedp.NewContext(
+		actx,
+		//chromedp.WithLogf(log.Printf),
+		//chromedp.WithDebugf(log.Printf),
+		//chromedp.WithErrorf(log.Printf))
+	)
+
+	b.ctx = ctx
+	b.cancel = func() {
+		cancel()
+		allocatorCancel()
+	}
+
+	if options.RecordEvents {
+		pth := filepath.Join(b.savePath, "browser_events.jsonl")
+
+		if b.events, err = newEventRecorder(pth); err != nil {
+			log.Fatalf("Was unable to open file: %s, due to error: %v", pth, err)
+		}
+
+		b.targetListeners = append(b.targetListeners, b.events.listener)
+	}
+
+	if options.RecordHar {
+		var fetchBody func(ctx context.Context, id network.RequestID) ([]byte, error)
+
+		if options.HarBodies {
+			fetchBody = fetchResponseBody
+		}
 
-		b.ctx, b.cancel = chromedp.NewContext(
-			actx,
-			//chromedp.WithLogf(log.Printf),
-			//chromedp.WithDebugf(log.Printf),
-			//chromedp.WithErrorf(log.Printf))
-		)
+		b.har = newHarRecorder(fetchBody)
+		b.targetListeners = append(b.targetListeners, b.har.listener)
 	}
 
-	if timeout != nil {
-		b.ctx, b.cancel = context.WithTimeout(b.ctx, time.Duration(*timeout)*time.Second)
+	if len(options.NetworkRules) > 0 {
+		rules, err := compileNetworkRules(options.NetworkRules)
+		if err != nil {
+			log.Fatalf("Invalid network rules: %v", err)
+		}
+
+		b.targetListeners = append(b.targetListeners, func(ctx context.Context) func(ev interface{}) {
+			return interceptRequests(ctx, rules)
+		})
+
+		// every request is paused so the rules are matched in order here rather than by the browser
+		b.tabSetup = append(b.tabSetup, fetch.Enable().WithPatterns([]*fetch.RequestPattern{{URLPattern: "*"}}))
 	}
 
-	b.htmlMap = make(map[string]*string)
+	if options.DialogPolicy != nil {
+		pth := filepath.Join(b.savePath, "dialogs.jsonl")
+
+		if b.dialogs, err = newDialogHandler(*options.DialogPolicy, pth); err != nil {
+			log.Fatalf("Invalid dialog policy: %v", err)
+		}
+
+		b.targetListeners = append(b.targetListeners, b.dialogs.listener)
+	}
+
+	if options.SaveDownloads {
+		// the download behavior applies to the whole browser and the path must exist on the machine running it
+		if options.RemoteDebuggingUrl != "" {
+			log.Fatalf("save_downloads can not be used with remote_debugging_url")
+		}
+
+		downloadPath, err := filepath.Abs(filepath.Join(b.savePath, "downloads"))
+		if err != nil {
+			log.Fatalf("Unable to resolve the downloads folder: %v", err)
+		}
+
+		if err = os.MkdirAll(downloadPath, 0777); err != nil {
+			log.Fatalf("Was unable to create folder: %s, due to error: %v", downloadPath, err)
+		}
+
+		if b.downloads, err = newDownloadRecorder(downloadPath); err != nil {
+			log.Fatalf("Was unable to read folder: %s, due to error: %v", downloadPath, err)
+		}
+
+		b.targetListeners = append(b.targetListeners, b.downloads.listener)
+		b.tabSetup = append(b.tabSetup, b.downloads.behaviorAction())
+	}
+
+	b.emulation = emulationState{Settings: options.Emulation}
+
+	if options.Emulation != nil {
+		emulationTasks, err := options.Emulation.actions()
+		if err != nil {
+			log.Fatalf("Invalid emulation: %v", err)
+		}
+
+		b.tabSetup = append(b.tabSetup, emulationTasks)
+	}
+
+	// tabs opened later are set up the same way as the first one
+	b.listenTarget(b.ctx)
+	b.appendTask(b.tabSetup)
+
+	if options.Timeout != nil {
+		var timeoutCancel context.CancelFunc
+		b.ctx, timeoutCancel = context.WithTimeout(b.ctx, time.Duration(*options.Timeout)*time.Second)
+
+		browserCancel := b.cancel
+		b.cancel = func() {
+			timeoutCancel()
+			browserCancel()
+		}
+	}
+
+	b.htmlList = make([]*htmlMetaData, 0, 10)
 	b.nodeMap = make(map[string]*[]*nodeWithStyles)
 	b.imageList = make([]*imageMetaData, 0, 10)
+	b.fileList = make([]*fileMetaData, 0, 10)
 	b.locationMap = make(map[string][]*string)
+	b.evalMap = make(map[string]map[string]*[]byte)
+	b.markdownList = make([]*markdownMetaData, 0, 10)
+	b.axTreeMap = make(map[string]*[]axNodeMetaData)
+	b.auditMap = make(map[string]*auditMetaData)
+	b.styledSnapshots = make(map[string]bool)
+	b.emulationMap = make(map[string]emulationState)
+	b.storageList = make([]*storageMetaData, 0, 10)
+	b.tabMap = make(map[string]*[]tabInfo)
 
 	return b
 }
@@ -86,10 +282,13 @@ func (b *Executor) appendTask(action chromedp.Action) {
 }
 
 func (b *Executor) Navigate(url string) {
+	b.leaveFrames()
 	b.tasks = append(b.tasks, chromedp.Navigate(url))
 }
 
 func (b *Executor) FullPageScreenShot(quality uint8, name, snapshot string) {
+	b.recordEmulation(snapshot)
+
 	var buf []byte
 	var imageData imageMetaData
 	b.appendTask(chromedp.FullScreenshot(&buf, int(quality)))
@@ -101,11 +300,62 @@ func (b *Executor) FullPageScreenShot(quality uint8, name, snapshot string) {
 	b.imageList = append(b.imageList, &imageData)
 }
 
-func (b *Executor) ElementScreenshot(scale float64, selector string, name, snapshot string) {
+// PrintPdf
+/*
+Prints the page as a paginated pdf
+*/
+func (b *Executor) PrintPdf(name, snapshot string, options PdfOptions) {
+	b.recordEmulation(snapshot)
+
+	var buf []byte
+
+	b.appendTask(chromedp.ActionFunc(func(c context.Context) error {
+		params := page.PrintToPDF().
+			WithLandscape(options.Landscape).
+			WithPrintBackground(options.PrintBackground)
+
+		if options.PaperWidth > 0 && options.PaperHeight > 0 {
+			params = params.WithPaperWidth(options.PaperWidth).WithPaperHeight(options.PaperHeight)
+		}
+
+		if options.Margins != nil {
+			params = params.
+				WithMarginTop(options.Margins[0]).
+				WithMarginBottom(options.Margins[1]).
+				WithMarginLeft(options.Margins[2]).
+				WithMarginRight(options.Margins[3])
+		}
+
+		if options.PageRanges != "" {
+			params = params.WithPageRanges(options.PageRanges)
+		}
+
+		var err error
+		buf, _, err = params.Do(c)
+		return err
+	}))
+
+	b.fileList = append(b.fileList, &fileMetaData{
+		snapShotName: snapshot,
+		fileName:     name,
+		byteData:     &buf,
+	})
+}
+
+func (b *Executor) ElementScreenshot(
+	scale float64,
+	selector string,
+	name,
+	snapshot string,
+	queryFunc func(s *chromedp.Selector)) {
+	queryFunc = b.inFrame(queryFunc)
+
+	b.recordEmulation(snapshot)
+
 	var buf []byte
 	var imageData imageMetaData
-	b.appendTask(chromedp.WaitVisible(selector))
-	b.appendTask(chromedp.ScreenshotScale(selector, scale, &buf, chromedp.NodeVisible))
+	b.appendFrameTask(chromedp.WaitVisible(selector, queryFunc))
+	b.appendFrameTask(chromedp.ScreenshotScale(selector, scale, &buf, queryFunc, chromedp.NodeVisible))
 
 	imageData.byteData = &buf
 	imageData.snapShotName = snapshot
@@ -119,7 +369,242 @@ func (b *Executor) ElementScreenshot(scale float64, selector string, name, snaps
 Instructs the browser agent to click on a section of the webpage
 */
 func (b *Executor) Click(selector string, queryFunc func(s *chromedp.Selector)) {
-	b.appendTask(chromedp.Click(selector, queryFunc))
+	queryFunc = b.inFrame(queryFunc)
+
+	b.appendFrameTask(chromedp.Click(selector, queryFunc))
+}
+
+// TypeText
+/*
+Types text into an input element, optionally clearing the existing value before typing
+*/
+func (b *Executor) TypeText(selector, text string, clearFirst bool, queryFunc func(s *chromedp.Selector)) {
+	queryFunc = b.inFrame(queryFunc)
+
+	if clearFirst {
+		b.appendFrameTask(chromedp.SetValue(selector, "", queryFunc))
+	}
+
+	b.appendFrameTask(chromedp.SendKeys(selector, text, queryFunc))
+}
+
+// ScrollToElement
+/*
+Scrolls the page until the element is in view
+*/
+func (b *Executor) ScrollToElement(selector string, queryFunc func(s *chromedp.Selector)) {
+	queryFunc = b.inFrame(queryFunc)
+
+	b.appendFrameTask(chromedp.ScrollIntoView(selector, queryFunc))
+}
+
+// ScrollByOffset
+/*
+Scrolls the page by a pixel offset relative to the current scroll position
+*/
+func (b *Executor) ScrollByOffset(x, y int64) {
+	b.appendTask(chromedp.Evaluate(fmt.Sprintf("window.scrollBy(%d, %d)", x, y), nil))
+}
+
+// ScrollToBottom
+/*
+Scrolls to the bottom of the page
+*/
+func (b *Executor) ScrollToBottom() {
+	b.appendTask(chromedp.Evaluate(scrollToBottomJs, nil))
+}
+
+// ScrollUntilStable
+/*
+Keeps scrolling to the bottom of the page until it stops loading new content
+*/
+func (b *Executor) ScrollUntilStable(
+	iterLimit uint16,
+	pauseTime uint32,
+	snapshotName string,
+	imageQuality uint8,
+	saveImg bool,
+	saveHtml bool,
+) {
+	pImgList := &b.imageList
+	if !saveImg {
+		pImgList = nil
+	}
+
+	pHtmlList := &b.htmlList
+	if !saveHtml {
+		pHtmlList = nil
+	}
+
+	b.appendTask(
+		scrollUntilStableAction(
+			iterLimit, pauseTime, snapshotName, imageQuality, pHtmlList, pImgList, b.snapshotRecorder(),
+		),
+	)
+}
+
+// EmulateMedia
+/*
+Emulates a css media type and media features in the active tab, it replaces any earlier media emulation of the tab.
+An empty emulation turns it off
+*/
+func (b *Executor) EmulateMedia(media MediaEmulation) {
+	b.appendTask(emulation.SetEmulatedMedia().WithMedia(media.Media).WithFeatures(media.features()))
+	b.updateEmulation(func(state *emulationState) {
+		state.Media = nil
+		if media != (MediaEmulation{}) {
+			state.Media = &media
+		}
+	})
+}
+
+// EmulateVisionDeficiency
+/*
+Renders the page of the active tab as seen with a vision deficiency
+*/
+func (b *Executor) EmulateVisionDeficiency(deficiency emulation.SetEmulatedVisionDeficiencyType) {
+	b.appendTask(emulation.SetEmulatedVisionDeficiency(deficiency))
+	b.updateEmulation(func(state *emulationState) {
+		state.VisionDeficiency = ""
+		if deficiency != emulation.SetEmulatedVisionDeficiencyTypeNone {
+			state.VisionDeficiency = deficiency.String()
+		}
+	})
+}
+
+// resolveFirstNode
+/*
+returns the first node matching the selector
+*/
+func resolveFirstNode(c context.Context, selector string, queryFunc func(s *chromedp.Selector)) (*cdp.Node, error) {
+	var nodes []*cdp.Node
+	if err := chromedp.Nodes(selector, &nodes, queryFunc).Do(c); err != nil {
+		return nil, err
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("selector %s did not return any nodes", selector)
+	}
+
+	return nodes[0], nil
+}
+
+// SelectOption
+/*
+Selects the option with the matching value in a dropdown and notifies the page of the change
+*/
+func (b *Executor) SelectOption(selector, value string, queryFunc func(s *chromedp.Selector)) {
+	queryFunc = b.inFrame(queryFunc)
+
+	b.appendFrameTask(chromedp.SetValue(selector, value, queryFunc))
+	b.appendFrameTask(chromedp.ActionFunc(func(c context.Context) error {
+		node, err := resolveFirstNode(c, selector, queryFunc)
+		if err != nil {
+			return err
+		}
+
+		obj, err := dom.ResolveNode().WithNodeID(node.NodeID).Do(c)
+		if err != nil {
+			return err
+		}
+
+		_, exp, err := runtime.CallFunctionOn(
+			`function() {
+				this.dispatchEvent(new Event('input', {bubbles: true}));
+				this.dispatchEvent(new Event('change', {bubbles: true}));
+			}`).WithObjectID(obj.ObjectID).Do(c)
+
+		if err != nil {
+			return err
+		}
+
+		if exp != nil {
+			return exp
+		}
+
+		return nil
+	}))
+}
+
+// Hover
+/*
+Moves the mouse over the center of an element
+*/
+func (b *Executor) Hover(selector string, queryFunc func(s *chromedp.Selector)) {
+	queryFunc = b.inFrame(queryFunc)
+
+	b.appendFrameTask(chromedp.ActionFunc(func(c context.Context) error {
+		node, err := resolveFirstNode(c, selector, queryFunc)
+		if err != nil {
+			return err
+		}
+
+		if err = dom.ScrollIntoViewIfNeeded().WithNodeID(node.NodeID).Do(c); err != nil {
+			return err
+		}
+
+		quads, err := dom.GetContentQuads().WithNodeID(node.NodeID).Do(c)
+		if err != nil {
+			return err
+		}
+
+		if len(quads) == 0 || len(quads[0]) != 8 {
+			return fmt.Errorf("element %s has no dimensions to hover over", selector)
+		}
+
+		var x, y float64
+		for i := 0; i < len(quads[0]); i += 2 {
+			x += quads[0][i]
+			y += quads[0][i+1]
+		}
+
+		return input.DispatchMouseEvent(input.MouseMoved, x/4, y/4).Do(c)
+	}))
+}
+
+// PressKey
+/*
+Presses a key with optional modifiers, if a selector is provided the element is focused first
+*/
+func (b *Executor) PressKey(selector, key string, modifiers []input.Modifier, queryFunc func(s *chromedp.Selector)) {
+	queryFunc = b.inFrame(queryFunc)
+
+	if selector != "" {
+		b.appendFrameTask(chromedp.Focus(selector, queryFunc))
+	}
+
+	b.appendTask(chromedp.KeyEvent(key, chromedp.KeyModifiers(modifiers...)))
+}
+
+// Check
+/*
+Clicks a checkbox or radio button if its checked state does not match the wanted state
+*/
+func (b *Executor) Check(selector string, checked bool, queryFunc func(s *chromedp.Selector)) {
+	queryFunc = b.inFrame(queryFunc)
+
+	b.appendFrameTask(chromedp.ActionFunc(func(c context.Context) error {
+		var current bool
+		if err := chromedp.JavascriptAttribute(selector, "checked", &current, queryFunc).Do(c); err != nil {
+			return err
+		}
+
+		if current == checked {
+			return nil
+		}
+
+		return chromedp.Click(selector, queryFunc).Do(c)
+	}))
+}
+
+// SetFileInput
+/*
+Attaches local files to a file input as if the user had picked them
+*/
+func (b *Executor) SetFileInput(selector string, files []string, queryFunc func(s *chromedp.Selector)) {
+	queryFunc = b.inFrame(queryFunc)
+
+	b.appendFrameTask(setFileInputAction(selector, files, queryFunc))
 }
 
 // SleepForSeconds
@@ -131,15 +616,118 @@ func (b *Executor) SleepForSeconds(seconds uint16) {
 		chromedp.Sleep(time.Duration(seconds) * time.Second))
 }
 
+// WaitForElement
+/*
+Pauses operations until the element meets the condition (visible, ready, enabled, not_present) or the timeout is hit
+*/
+func (b *Executor) WaitForElement(
+	selector,
+	condition string,
+	timeout time.Duration,
+	queryFunc func(s *chromedp.Selector)) {
+
+	queryFunc = b.inFrame(queryFunc)
+
+	b.appendFrameTask(chromedp.ActionFunc(func(c context.Context) error {
+		var action chromedp.QueryAction
+
+		switch condition {
+		case "visible":
+			action = chromedp.WaitVisible(selector, queryFunc)
+		case "ready":
+			action = chromedp.WaitReady(selector, queryFunc)
+		case "enabled":
+			action = chromedp.WaitEnabled(selector, queryFunc)
+		case "not_present":
+			action = chromedp.WaitNotPresent(selector, queryFunc)
+		default:
+			return fmt.Errorf("unsupported wait condition %s", condition)
+		}
+
+		tctx, cancel := context.WithTimeout(c, timeout)
+		defer cancel()
+
+		if err := action.Do(tctx); err != nil {
+			return fmt.Errorf("waiting for %s to be %s: %w", selector, condition, err)
+		}
+
+		return nil
+	}))
+}
+
+// WaitForUrl
+/*
+Pauses operations until the url of the page matches the pattern or the timeout is hit
+*/
+func (b *Executor) WaitForUrl(pattern *regexp.Regexp, timeout time.Duration) {
+	b.appendTask(chromedp.ActionFunc(func(c context.Context) error {
+		tctx, cancel := context.WithTimeout(c, timeout)
+		defer cancel()
+
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			var loc string
+			if err := chromedp.Location(&loc).Do(tctx); err != nil {
+				return err
+			}
+
+			if pattern.MatchString(loc) {
+				return nil
+			}
+
+			select {
+			case <-tctx.Done():
+				return fmt.Errorf("url %s never matched %s: %w", loc, pattern.String(), tctx.Err())
+			case <-ticker.C:
+			}
+		}
+	}))
+}
+
 // SaveSnapshot
 /*
-Collects all the HTML associated with a webpage, saves all operations that led to the creation of the html,
-we use it for snapshot purposes
+Collects the outer HTML of an element (the whole document by default), saves all operations that led to the creation
+of the html, we use it for snapshot purposes
 */
-func (b *Executor) SaveSnapshot(snapshotName string) {
+func (b *Executor) SaveSnapshot(selector, fileName, snapshotName string, queryFunc func(s *chromedp.Selector)) {
+	queryFunc = b.inFrame(queryFunc)
+
+	b.recordEmulation(snapshotName)
+
 	var snapShotHtml string
-	b.appendTask(chromedp.OuterHTML("body", &snapShotHtml))
-	b.htmlMap[snapshotName] = &snapShotHtml
+	b.appendFrameTask(chromedp.OuterHTML(selector, &snapShotHtml, queryFunc))
+	b.htmlList = append(b.htmlList, &htmlMetaData{
+		snapShotName: snapshotName,
+		fileName:     fileName,
+		html:         &snapShotHtml,
+	})
+}
+
+// SaveMhtml
+/*
+Saves the page as a self-contained mhtml archive including its css and images
+*/
+func (b *Executor) SaveMhtml(snapshotName string) {
+	b.recordEmulation(snapshotName)
+
+	fileMD := fileMetaData{
+		snapShotName: snapshotName,
+		fileName:     "page.mhtml",
+	}
+
+	b.appendTask(chromedp.ActionFunc(func(c context.Context) error {
+		err, md := writeMhtml(snapshotName, c)
+		if err != nil {
+			return err
+		}
+
+		fileMD.byteData = md.byteData
+		return nil
+	}))
+
+	b.fileList = append(b.fileList, &fileMD)
 }
 
 // parseThroughNodes
@@ -176,6 +764,13 @@ func parseThroughNodes(nodeSlice []*nodeWithStyles) []nodeMetaData {
 			CssStyles:  cssMap,
 		}
 
+		if geometry := nodeMd.geometry; geometry != nil {
+			metaData.BoxModel = geometry.boxModel
+			metaData.Visible = &geometry.visible
+			metaData.InViewport = &geometry.inViewport
+			metaData.Text = &geometry.text
+		}
+
 		nodeMetaDataSlice = append(nodeMetaDataSlice, metaData)
 	}
 
@@ -193,6 +788,20 @@ func (b *Executor) createSnapshotFolder(snapshot string) string {
 		log.Fatal("Could not create directory: " + folderPath)
 	}
 
+	if state, ok := b.emulationMap[snapshot]; ok {
+		pth := filepath.Join(folderPath, "emulation.json")
+
+		byteSlice, err := json.MarshalIndent(state, "", "    ")
+
+		if err != nil {
+			log.Fatalf("Unable to marshal emulation: %v", err)
+		}
+
+		if err := os.WriteFile(pth, byteSlice, 0666); err != nil {
+			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+		}
+	}
+
 	return folderPath
 }
 
@@ -200,7 +809,10 @@ func populatedNodeAction(
 	selector string,
 	prepopulate bool,
 	recurse bool,
-	nodesWithStyles *[]*nodeWithStyles) chromedp.Tasks {
+	getStyles bool,
+	getGeometry bool,
+	nodesWithStyles *[]*nodeWithStyles,
+	queryFunc func(s *chromedp.Selector)) chromedp.Tasks {
 	return chromedp.Tasks{
 		chromedp.ActionFunc(func(c context.Context) error {
 			var popSlice []chromedp.PopulateOption
@@ -213,6 +825,7 @@ func populatedNodeAction(
 			err := chromedp.Nodes(
 				selector,
 				&nodeSlice,
+				queryFunc,
 				chromedp.Populate(-1, true, popSlice...),
 			).Do(c)
 
@@ -226,12 +839,27 @@ func populatedNodeAction(
 					nodeSlice = flattenNode(nodeSlice)
 				}
 
+				var vp viewport
+				if getGeometry {
+					if vp, err = currentViewport(c); err != nil {
+						return err
+					}
+				}
+
 				for _, node := range nodeSlice {
-					if cs, err := css.GetComputedStyleForNode(node.NodeID).Do(c); err == nil {
-						*nodesWithStyles = append(*nodesWithStyles, &nodeWithStyles{cs, node})
-					} else {
-						*nodesWithStyles = append(*nodesWithStyles, &nodeWithStyles{node: node})
+					styledNode := nodeWithStyles{node: node}
+
+					if getStyles {
+						if cs, err := css.GetComputedStyleForNode(node.NodeID).Do(c); err == nil {
+							styledNode.cssStyles = cs
+						}
+					}
+
+					if getGeometry {
+						styledNode.geometry = getNodeGeometry(c, node, vp)
 					}
+
+					*nodesWithStyles = append(*nodesWithStyles, &styledNode)
 				}
 			}
 
@@ -250,31 +878,73 @@ func (b *Executor) CollectNodes(
 	prepopulate,
 	waitReady,
 	recurse,
-	nodesWithStyles bool,
-
+	nodesWithStyles,
+	getGeometry bool,
+	queryFunc func(s *chromedp.Selector),
 ) {
+	queryFunc = b.inFrame(queryFunc)
+
+	b.recordEmulation(snapshotName)
 
 	nodeSlice := make([]*nodeWithStyles, 0, 100)
 
 	if waitReady {
-		b.appendTask(chromedp.WaitReady(selector))
+		b.appendFrameTask(chromedp.WaitReady(selector, queryFunc))
 	}
 
-	b.appendTask(
+	b.appendFrameTask(
 		populatedNodeAction(
 			selector,
 			prepopulate,
 			recurse,
+			nodesWithStyles,
+			getGeometry,
 			func() *[]*nodeWithStyles {
-				if nodesWithStyles {
+				if nodesWithStyles || getGeometry {
 					return &nodeSlice
 				} else {
 					return nil
 				}
-			}()),
+			}(),
+			queryFunc),
 	)
 
 	b.nodeMap[snapshotName] = &nodeSlice
+	b.styledSnapshots[snapshotName] = recurse && nodesWithStyles
+}
+
+// CollectAccessibilityTree
+/*
+Collects the accessibility tree that assistive technology sees, with the role, name, description and states of
+every node
+*/
+func (b *Executor) CollectAccessibilityTree(snapshotName string, includeIgnored bool) {
+	b.recordEmulation(snapshotName)
+
+	axSlice := make([]axNodeMetaData, 0, 100)
+	b.appendTask(axTreeAction(includeIgnored, &axSlice))
+	b.axTreeMap[snapshotName] = &axSlice
+}
+
+// AuditAccessibility
+/*
+Runs the registered WCAG rules over every element on the page and its computed styles
+*/
+func (b *Executor) AuditAccessibility(snapshotName string, ruleIds []string) {
+	b.recordEmulation(snapshotName)
+
+	// the nodes collect_nodes gathered for the snapshot are reused when they include every element with its styles
+	nodes, ok := b.nodeMap[snapshotName]
+	if !ok || !b.styledSnapshots[snapshotName] {
+		nodeSlice := make([]*nodeWithStyles, 0, 100)
+		b.appendTask(populatedNodeAction("html", true, true, true, false, &nodeSlice, chromedp.ByQuery))
+		nodes = &nodeSlice
+	}
+
+	b.auditMap[snapshotName] = &auditMetaData{
+		ruleIds: ruleIds,
+		nodes:   nodes,
+	}
 }
 
 func (b *Executor) HtmlIterator(
@@ -286,16 +956,16 @@ func (b *Executor) HtmlIterator(
 	saveImg bool,
 	saveHtml bool,
 	saveNodes bool,
+	saveMhtml bool,
 ) {
-
 	pImgList := &b.imageList
 	if !saveImg {
 		pImgList = nil
 	}
 
-	pHtmlMap := b.htmlMap
+	pHtmlList := &b.htmlList
 	if !saveHtml {
-		pHtmlMap = nil
+		pHtmlList = nil
 	}
 
 	pNodeMap := b.nodeMap
@@ -303,14 +973,99 @@ func (b *Executor) HtmlIterator(
 		pNodeMap = nil
 	}
 
+	pFileList := &b.fileList
+	if !saveMhtml {
+		pFileList = nil
+	}
+
 	b.appendTask(
 		htmlIteratorAction(
-			iterLimit, pauseTime, startingSnapshot, snapshotName, imageQuality, pHtmlMap, pNodeMap, pImgList,
+			iterLimit,
+			pauseTime,
+			startingSnapshot,
+			snapshotName,
+			imageQuality,
+			pHtmlList,
+			pNodeMap,
+			pImgList,
+			pFileList,
+			b.snapshotRecorder(),
 		),
 	)
 }
 
+// SaveStorageState
+/*
+Saves the cookies of the current page and the local and session storage of its origin to a json file in the session
+folder
+*/
+func (b *Executor) SaveStorageState(fileName string) {
+	state := &storageState{}
+
+	b.appendTask(saveStorageAction(state))
+
+	b.storageList = append(b.storageList, &storageMetaData{
+		fileName: fileName,
+		state:    state,
+	})
+}
+
+// LoadStorageState
+/*
+Restores the storage state saved by a session, the current one when sessionId is empty. Cookies are always set while
+web storage is only restored when the page is on an origin the file holds
+*/
+func (b *Executor) LoadStorageState(sessionId, fileName string) {
+	sessionPath := b.savePath
+	if sessionId != "" {
+		sessionPath = filepath.Join(filepath.Dir(b.savePath), sessionId)
+	}
+
+	b.appendTask(loadStorageAction(filepath.Join(sessionPath, fileName)))
+}
+
+// NewTab
+/*
+Opens a tab, loading the url when one is given, and runs the following tasks in it
+*/
+func (b *Executor) NewTab(name, url string) {
+	b.leaveFrames()
+	b.appendTask(newTabAction(b, name, url))
+}
+
+// SwitchTab
+/*
+Runs the following tasks in the tab matching the selector, including tabs the page opened by itself
+*/
+func (b *Executor) SwitchTab(selector TabSelector) {
+	b.leaveFrames()
+	b.appendTask(switchTabAction(b, selector))
+}
+
+// ListTabs
+/*
+Saves the index, name, url and title of every open tab as tabs.json in the snapshot
+*/
+func (b *Executor) ListTabs(snapshotName string) {
+	b.recordEmulation(snapshotName)
+
+	tabs := make([]tabInfo, 0, 10)
+	b.appendTask(listTabsAction(b, &tabs))
+	b.tabMap[snapshotName] = &tabs
+}
+
+// CloseTab
+/*
+Closes the tab matching the selector, closing the active tab moves the following tasks to the tab opened before it
+*/
+func (b *Executor) CloseTab(selector TabSelector) {
+	b.leaveFrames()
+	b.appendTask(closeTabAction(b, selector))
+}
+
 func (b *Executor) AcquireLocation(snapshot string) {
+	b.recordEmulation(snapshot)
+
 	var loc string
 
 	b.appendTask(chromedp.ActionFunc(func(c context.Context) error {
@@ -324,10 +1079,135 @@ func (b *Executor) AcquireLocation(snapshot string) {
 	b.locationMap[snapshot] = append(b.locationMap[snapshot], &loc)
 }
 
+// EvaluateJs
+/*
+Runs a javascript expression on the page and saves the json result under the key in the snapshot
+*/
+func (b *Executor) EvaluateJs(expression, key, snapshot string, awaitPromise bool) {
+	b.recordEmulation(snapshot)
+
+	var res []byte
+
+	b.appendTask(chromedp.Evaluate(expression, &res, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(awaitPromise)
+	}))
+
+	if _, ok := b.evalMap[snapshot]; !ok {
+		b.evalMap[snapshot] = make(map[string]*[]byte)
+	}
+
+	b.evalMap[snapshot][key] = &res
+}
+
+// ExtractText
+/*
+Converts the page into markdown and saves it as content.md in the snapshot. When a source file is given the html
+saved under that name in the snapshot is converted instead of the live page
+*/
+func (b *Executor) ExtractText(snapshot, sourceFile string, mainContent bool) {
+	b.recordEmulation(snapshot)
+
+	mdMetaData := markdownMetaData{
+		snapShotName: snapshot,
+		sourceFile:   sourceFile,
+		mainContent:  mainContent,
+	}
+
+	if sourceFile == "" {
+		var pageHtml string
+		b.appendTask(chromedp.OuterHTML("html", &pageHtml, chromedp.ByQuery))
+		mdMetaData.html = &pageHtml
+	}
+
+	b.markdownList = append(b.markdownList, &mdMetaData)
+}
+
 func (b *Executor) Execute() {
 	defer b.cancel()
-	if err := chromedp.Run(b.ctx, b.tasks); err != nil {
-		log.Fatalf("Unable to run browser tasks due to: %v", err)
+
+	// the browser is started on its own so the first tab is known before any tab command runs
+	if err := chromedp.Run(b.ctx); err != nil {
+		log.Fatalf("Unable to start the browser due to: %v", err)
+	}
+
+	b.initTabs()
+
+	// tasks are run one at a time because a tab command changes the tab the tasks after it run in
+	for _, task := range b.tasks {
+		if err := chromedp.Run(b.activeContext(), task); err != nil {
+			log.Fatalf("Unable to run browser tasks due to: %v", err)
+		}
+	}
+
+	// downloads started by the last tasks may still be running
+	if b.downloads != nil && !b.downloads.wait(downloadTimeout) {
+		log.Printf("downloads still running after %s were not saved", downloadTimeout)
+	}
+
+	if b.events != nil {
+		b.events.close()
+	}
+
+	if b.dialogs != nil {
+		b.dialogs.close()
+	}
+
+	if b.har != nil {
+		pth := filepath.Join(b.savePath, "network.har")
+
+		harFile, err := appendHar(pth, b.har.har())
+
+		if err != nil {
+			log.Fatalf("Unable to read the har file of the session: %v", err)
+		}
+
+		byteSlice, err := json.MarshalIndent(harFile, "", "    ")
+
+		if err != nil {
+			log.Fatalf("Unable to marshal har file: %v", err)
+		}
+
+		if err := os.WriteFile(pth, byteSlice, 0666); err != nil {
+			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+		}
+	}
+
+	b.closeTabs()
+
+	if b.downloads != nil && len(b.downloads.manifest()) > 0 {
+		pth := filepath.Join(b.downloads.dir, "manifest.json")
+
+		manifest, err := appendManifest(pth, b.downloads.manifest())
+
+		if err != nil {
+			log.Fatalf("Unable to read the download manifest of the session: %v", err)
+		}
+
+		byteSlice, err := json.MarshalIndent(manifest, "", "    ")
+
+		if err != nil {
+			log.Fatalf("Unable to marshal download manifest: %v", err)
+		}
+
+		if err := os.WriteFile(pth, byteSlice, 0666); err != nil {
+			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+		}
+	}
+
+	for snapShotName, tabs := range b.tabMap {
+		folderPath := b.createSnapshotFolder(snapShotName)
+
+		pth := filepath.Join(folderPath, "tabs.json")
+
+		byteSlice, err := json.MarshalIndent(tabs, "", "    ")
+
+		if err != nil {
+			log.Fatalf("Unable to marshal tabs: %v", err)
+		}
+
+		if err := os.WriteFile(pth, byteSlice, 0666); err != nil {
+			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+		}
 	}
 
 	for _, imd := range b.imageList {
@@ -339,13 +1219,22 @@ func (b *Executor) Execute() {
 		}
 	}
 
-	for snapShotName, html := range b.htmlMap {
+	for _, fmd := range b.fileList {
 
-		folderPath := b.createSnapshotFolder(snapShotName)
+		folderPath := b.createSnapshotFolder(fmd.snapShotName)
+		pth := filepath.Join(folderPath, fmd.fileName)
+		if err := os.WriteFile(pth, *fmd.byteData, 0666); err != nil {
+			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+		}
+	}
+
+	for _, hmd := range b.htmlList {
 
-		pth := filepath.Join(folderPath, "body.txt")
+		folderPath := b.createSnapshotFolder(hmd.snapShotName)
 
-		byteSlice := []byte(*html)
+		pth := filepath.Join(folderPath, hmd.fileName)
+
+		byteSlice := []byte(*hmd.html)
 		if err := os.WriteFile(pth, byteSlice, 0666); err != nil {
 			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
 		}
@@ -369,6 +1258,40 @@ func (b *Executor) Execute() {
 		}
 	}
 
+	for snapShotName, axSlice := range b.axTreeMap {
+		folderPath := b.createSnapshotFolder(snapShotName)
+
+		pth := filepath.Join(folderPath, "axTree.json")
+
+		byteSlice, err := json.MarshalIndent(axSlice, "", "    ")
+
+		if err != nil {
+			log.Fatalf("Unable to marshal accessibility tree: %v", err)
+		}
+
+		if err := os.WriteFile(pth, byteSlice, 0666); err != nil {
+			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+		}
+	}
+
+	for snapShotName, amd := range b.auditMap {
+		folderPath := b.createSnapshotFolder(snapShotName)
+
+		pth := filepath.Join(folderPath, "audit.json")
+
+		report := runAudit(newAuditDocument(*amd.nodes), amd.ruleIds)
+
+		byteSlice, err := json.MarshalIndent(report, "", "    ")
+
+		if err != nil {
+			log.Fatalf("Unable to marshal audit report: %v", err)
+		}
+
+		if err := os.WriteFile(pth, byteSlice, 0666); err != nil {
+			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+		}
+	}
+
 	for snapShotName, location := range b.locationMap {
 		folderPath := b.createSnapshotFolder(snapShotName)
 		pth := filepath.Join(folderPath, "locationData.json")
@@ -383,8 +1306,81 @@ func (b *Executor) Execute() {
 		}
 	}
 
-	b.htmlMap = make(map[string]*string)
+	for snapShotName, results := range b.evalMap {
+		folderPath := b.createSnapshotFolder(snapShotName)
+
+		for key, res := range results {
+			pth := filepath.Join(folderPath, fmt.Sprintf("eval_%s.json", key))
+
+			// undefined and null results carry no value
+			raw := *res
+			if raw == nil {
+				raw = []byte("null")
+			}
+
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, raw, "", "    "); err != nil {
+				log.Fatalf("Unable to format evaluation result: %v", err)
+			}
+
+			if err := os.WriteFile(pth, buf.Bytes(), 0666); err != nil {
+				log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+			}
+		}
+	}
+
+	for _, smd := range b.storageList {
+		pth := filepath.Join(b.savePath, smd.fileName)
+
+		byteSlice, err := json.MarshalIndent(smd.state, "", "    ")
+
+		if err != nil {
+			log.Fatalf("Unable to marshal storage state: %v", err)
+		}
+
+		if err := os.WriteFile(pth, byteSlice, 0666); err != nil {
+			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+		}
+	}
+
+	// markdown is converted last so html saved by this operation can be used as the source
+	for _, mmd := range b.markdownList {
+		folderPath := b.createSnapshotFolder(mmd.snapShotName)
+
+		pageHtml := mmd.html
+		if pageHtml == nil {
+			src := filepath.Join(folderPath, mmd.sourceFile)
+			byteSlice, err := os.ReadFile(src)
+			if err != nil {
+				log.Fatalf("Was unable to read file: %s, due to error: %v", src, err)
+			}
+
+			htmlString := string(byteSlice)
+			pageHtml = &htmlString
+		}
+
+		markdown, err := htmlToMarkdown(*pageHtml, mmd.mainContent)
+		if err != nil {
+			log.Fatalf("Unable to convert html to markdown: %v", err)
+		}
+
+		pth := filepath.Join(folderPath, "content.md")
+		if err := os.WriteFile(pth, []byte(markdown), 0666); err != nil {
+			log.Fatalf("Was unable to write file: %s, due to error: %v", pth, err)
+		}
+	}
+
+	b.htmlList = make([]*htmlMetaData, 0, 10)
 	b.nodeMap = make(map[string]*[]*nodeWithStyles)
 	b.imageList = make([]*imageMetaData, 0, 10)
+	b.fileList = make([]*fileMetaData, 0, 10)
 	b.locationMap = make(map[string][]*string)
+	b.evalMap = make(map[string]map[string]*[]byte)
+	b.markdownList = make([]*markdownMetaData, 0, 10)
+	b.axTreeMap = make(map[string]*[]axNodeMetaData)
+	b.auditMap = make(map[string]*auditMetaData)
+	b.styledSnapshots = make(map[string]bool)
+	b.emulationMap = make(map[string]emulationState)
+	b.storageList = make([]*storageMetaData, 0, 10)
+	b.tabMap = make(map[string]*[]tabInfo)
 }
@@ -2,12 +2,18 @@ package command
 
 import (
 	"agent/browser"
+	"agent/helper"
 	"errors"
 	"fmt"
-	"github.com/chromedp/chromedp"
-	"log"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp/kb"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type Params interface {
@@ -58,13 +64,17 @@ func (o *OpenWebPage) AppendTask(b *browser.Executor) {
 }
 
 type ElementScreenshot struct {
+	ElementSelector
 	Scale          float64 `json:"scale"`
 	Name           string  `json:"name"`
-	Selector       string  `json:"selector"`
 	SnapShotFolder string  `json:"snapshot_name"`
 }
 
 func (e *ElementScreenshot) Validate() error {
+	if err := e.validateSelector(); err != nil {
+		return err
+	}
+
 	if !strings.HasSuffix(e.Name, ".png") {
 		return errors.New("name must end with .png")
 	}
@@ -77,19 +87,24 @@ func (e *ElementScreenshot) Validate() error {
 }
 
 func (e *ElementScreenshot) AppendTask(b *browser.Executor) {
-	b.ElementScreenshot(e.Scale, e.Selector, e.Name, e.SnapShotFolder)
+	b.ElementScreenshot(e.Scale, e.Selector, e.Name, e.SnapShotFolder, e.queryOption())
 }
 
 type CollectNodes struct {
-	Selector       string `json:"selector"`
+	ElementSelector
 	WaitReady      bool   `json:"wait_ready"`
 	GetStyles      bool   `json:"get_styles"`
+	GetGeometry    bool   `json:"get_geometry"`
 	Prepopulate    bool   `json:"prepopulate"`
 	Recurse        bool   `json:"recurse"`
 	SnapShotFolder string `json:"snapshot_name"`
 }
 
 func (c *CollectNodes) Validate() error {
+	if err := c.validateSelector(); err != nil {
+		return err
+	}
+
 	if strings.Contains(c.SnapShotFolder, ".") {
 		return errors.New("snapshot_folder must be folder not a file")
 	}
@@ -97,47 +112,87 @@ func (c *CollectNodes) Validate() error {
 }
 
 func (c *CollectNodes) AppendTask(b *browser.Executor) {
-	b.CollectNodes(c.Selector, c.SnapShotFolder, c.Prepopulate, c.WaitReady, c.Recurse, c.GetStyles)
+	b.CollectNodes(
+		c.Selector,
+		c.SnapShotFolder,
+		c.Prepopulate,
+		c.WaitReady,
+		c.Recurse,
+		c.GetStyles,
+		c.GetGeometry,
+		c.queryAllOption())
 }
 
-type Click struct {
-	Selector  string `json:"selector"`
-	QueryType string `json:"query_type"`
+type CollectAccessibilityTree struct {
+	IncludeIgnored bool   `json:"include_ignored"`
+	SnapShotFolder string `json:"snapshot_name"`
 }
 
-func (c *Click) Validate() error {
-
-	if c.Selector == "" {
-		return errors.New("selector is required")
+func (c *CollectAccessibilityTree) Validate() error {
+	if strings.Contains(c.SnapShotFolder, ".") {
+		return errors.New("snapshot_folder must be folder not a file")
 	}
+	return nil
+}
+
+func (c *CollectAccessibilityTree) AppendTask(b *browser.Executor) {
+	b.CollectAccessibilityTree(c.SnapShotFolder, c.IncludeIgnored)
+}
+
+type AuditAccessibility struct {
+	Rules          []string `json:"rules"`
+	SnapShotFolder string   `json:"snapshot_name"`
+}
 
-	validTypes := [1]string{
-		"search",
+func (a *AuditAccessibility) Validate() error {
+	if strings.Contains(a.SnapShotFolder, ".") {
+		return errors.New("snapshot_folder must be folder not a file")
 	}
 
-	for _, i := range validTypes {
-		if c.QueryType == i {
-			return nil
+	ruleIds := browser.AuditRuleIds()
+
+	for _, rule := range a.Rules {
+		if !helper.Contains[string](ruleIds, rule) {
+			return fmt.Errorf("rule %s not supported, options are %s", rule, strings.Join(ruleIds, ", "))
 		}
 	}
 
-	return fmt.Errorf("query type %s not supported", c.QueryType)
+	return nil
+}
+
+func (a *AuditAccessibility) AppendTask(b *browser.Executor) {
+	b.AuditAccessibility(a.SnapShotFolder, a.Rules)
+}
+
+type Click struct {
+	ElementSelector
+}
+
+func (c *Click) Validate() error {
+	return c.validateSelector()
 }
 
 func (c *Click) AppendTask(b *browser.Executor) {
-	var query func(s *chromedp.Selector)
+	b.Click(c.Selector, c.queryOption())
+}
 
-	switch c.QueryType {
-	case "search":
-		query = chromedp.BySearch
-	default:
-		log.Fatalf("unspported querytype %s", c.QueryType)
-	}
+type TypeText struct {
+	ElementSelector
+	Text       string `json:"text"`
+	ClearFirst bool   `json:"clear_first"`
+}
 
-	b.Click(c.Selector, query)
+func (t *TypeText) Validate() error {
+	return t.validateSelector()
+}
+
+func (t *TypeText) AppendTask(b *browser.Executor) {
+	b.TypeText(t.Selector, t.Text, t.ClearFirst, t.queryOption())
 }
 
 type SaveHtml struct {
+	ElementSelector
+	Name           string `json:"name"`
 	SnapShotFolder string `json:"snapshot_name"`
 }
 
@@ -145,11 +200,46 @@ func (s *SaveHtml) Validate() error {
 	if strings.Contains(s.SnapShotFolder, ".") {
 		return errors.New("snapshot_folder must be folder not a file")
 	}
-	return nil
+
+	if s.Selector == "" {
+		s.Selector = "html"
+		if s.QueryType == "" {
+			s.QueryType = "css"
+		}
+	}
+
+	if s.Name == "" {
+		s.Name = "page.html"
+	}
+
+	if !strings.HasSuffix(s.Name, ".html") {
+		return errors.New("name must end with .html")
+	}
+
+	if filepath.Base(s.Name) != s.Name {
+		return errors.New("name must be a file name not a path")
+	}
+
+	return s.validateSelector()
 }
 
 func (s *SaveHtml) AppendTask(b *browser.Executor) {
-	b.SaveSnapshot(s.SnapShotFolder)
+	b.SaveSnapshot(s.Selector, s.Name, s.SnapShotFolder, s.queryOption())
+}
+
+type SaveMhtml struct {
+	SnapShotFolder string `json:"snapshot_name"`
+}
+
+func (s *SaveMhtml) Validate() error {
+	if strings.Contains(s.SnapShotFolder, ".") {
+		return errors.New("snapshot_folder must be folder not a file")
+	}
+	return nil
+}
+
+func (s *SaveMhtml) AppendTask(b *browser.Executor) {
+	b.SaveMhtml(s.SnapShotFolder)
 }
 
 type Sleep struct {
@@ -172,6 +262,7 @@ type IterateHtml struct {
 	SaveHtml          bool    `json:"save_html"`
 	SaveNode          bool    `json:"save_node"`
 	SaveFullPageImage bool    `json:"save_full_page_image"`
+	SaveMhtml         bool    `json:"save_mhtml"`
 	ImageQuality      uint8   `json:"image_quality"`
 }
 
@@ -216,7 +307,8 @@ func (i *IterateHtml) AppendTask(b *browser.Executor) {
 		i.ImageQuality,
 		i.SaveFullPageImage,
 		i.SaveHtml,
-		i.SaveNode)
+		i.SaveNode,
+		i.SaveMhtml)
 }
 
 type AcquireLocation struct {
@@ -233,3 +325,620 @@ func (a *AcquireLocation) Validate() error {
 func (a *AcquireLocation) AppendTask(b *browser.Executor) {
 	b.AcquireLocation(a.SnapShotFolder)
 }
+
+// validateStorageFile
+/*
+storage state files live directly in a session folder
+*/
+func validateStorageFile(name string) error {
+	if !strings.HasSuffix(name, ".json") {
+		return errors.New("name must end with .json")
+	}
+
+	if filepath.Base(name) != name {
+		return errors.New("name must be a file name not a path")
+	}
+
+	return nil
+}
+
+type SaveStorageState struct {
+	Name string `json:"name"`
+}
+
+func (s *SaveStorageState) Validate() error {
+	if s.Name == "" {
+		s.Name = "storage_state.json"
+	}
+
+	return validateStorageFile(s.Name)
+}
+
+func (s *SaveStorageState) AppendTask(b *browser.Executor) {
+	b.SaveStorageState(s.Name)
+}
+
+type LoadStorageState struct {
+	Name      string `json:"name"`
+	SessionId string `json:"session_id"`
+}
+
+func (l *LoadStorageState) Validate() error {
+	if l.Name == "" {
+		l.Name = "storage_state.json"
+	}
+
+	if l.SessionId != "" && (filepath.Base(l.SessionId) != l.SessionId || l.SessionId == "..") {
+		return errors.New("session_id must be the name of a session")
+	}
+
+	return validateStorageFile(l.Name)
+}
+
+func (l *LoadStorageState) AppendTask(b *browser.Executor) {
+	b.LoadStorageState(l.SessionId, l.Name)
+}
+
+type NewTab struct {
+	Url  string `json:"url"`
+	Name string `json:"name"`
+}
+
+func (n *NewTab) Validate() error {
+	if n.Url != "" && !(strings.HasPrefix(n.Url, "http://") || strings.HasPrefix(n.Url, "https://")) {
+		return errors.New("url must begin with http:// or https://")
+	}
+
+	return nil
+}
+
+func (n *NewTab) AppendTask(b *browser.Executor) {
+	b.NewTab(n.Name, n.Url)
+}
+
+type SwitchTab struct {
+	TabSelector
+}
+
+func (s *SwitchTab) Validate() error {
+	return s.validateTabSelector(true)
+}
+
+func (s *SwitchTab) AppendTask(b *browser.Executor) {
+	b.SwitchTab(s.tabSelector())
+}
+
+type ListTabs struct {
+	SnapShotFolder string `json:"snapshot_name"`
+}
+
+func (l *ListTabs) Validate() error {
+	if strings.Contains(l.SnapShotFolder, ".") {
+		return errors.New("snapshot_folder must be folder not a file")
+	}
+	return nil
+}
+
+func (l *ListTabs) AppendTask(b *browser.Executor) {
+	b.ListTabs(l.SnapShotFolder)
+}
+
+type CloseTab struct {
+	TabSelector
+}
+
+func (c *CloseTab) Validate() error {
+	return c.validateTabSelector(false)
+}
+
+func (c *CloseTab) AppendTask(b *browser.Executor) {
+	b.CloseTab(c.tabSelector())
+}
+
+type EnterFrame struct {
+	ElementSelector
+}
+
+func (e *EnterFrame) Validate() error {
+	return e.validateSelector()
+}
+
+func (e *EnterFrame) AppendTask(b *browser.Executor) {
+	b.EnterFrame(e.Selector, e.queryOption())
+}
+
+type ExitFrame struct{}
+
+func (e *ExitFrame) Validate() error {
+	return nil
+}
+
+func (e *ExitFrame) AppendTask(b *browser.Executor) {
+	b.ExitFrame()
+}
+
+type WaitFor struct {
+	ElementSelector
+	Condition  string  `json:"condition"`
+	UrlPattern string  `json:"url_pattern"`
+	Timeout    *uint16 `json:"timeout"`
+	urlRegex   *regexp.Regexp
+}
+
+func (w *WaitFor) Validate() error {
+
+	if w.Timeout == nil {
+		timeout := uint16(10)
+		w.Timeout = &timeout
+	}
+
+	if *w.Timeout == 0 {
+		return errors.New("timeout must be greater than zero")
+	}
+
+	if w.Condition == "url_matches" {
+		if w.UrlPattern == "" {
+			return errors.New("url_pattern is required for the url_matches condition")
+		}
+
+		var err error
+		if w.urlRegex, err = regexp.Compile(w.UrlPattern); err != nil {
+			return fmt.Errorf("url_pattern is not a valid regex: %v", err)
+		}
+
+		return nil
+	}
+
+	validConditions := []string{
+		"visible", "ready", "enabled", "not_present",
+	}
+
+	if !helper.Contains[string](validConditions, w.Condition) {
+		return fmt.Errorf("condition %s not supported", w.Condition)
+	}
+
+	return w.validateSelector()
+}
+
+func (w *WaitFor) AppendTask(b *browser.Executor) {
+	timeout := time.Duration(*w.Timeout) * time.Second
+
+	if w.Condition == "url_matches" {
+		b.WaitForUrl(w.urlRegex, timeout)
+	} else {
+		b.WaitForElement(w.Selector, w.Condition, timeout, w.queryOption())
+	}
+}
+
+type Scroll struct {
+	ElementSelector
+	Mode string `json:"mode"`
+	X    int64  `json:"x"`
+	Y    int64  `json:"y"`
+}
+
+func (s *Scroll) Validate() error {
+	switch s.Mode {
+	case "element":
+		return s.validateSelector()
+	case "offset":
+		if s.X == 0 && s.Y == 0 {
+			return errors.New("x or y must be provided for the offset mode")
+		}
+		return nil
+	case "bottom":
+		return nil
+	default:
+		return fmt.Errorf("scroll mode %s not supported", s.Mode)
+	}
+}
+
+func (s *Scroll) AppendTask(b *browser.Executor) {
+	switch s.Mode {
+	case "element":
+		b.ScrollToElement(s.Selector, s.queryOption())
+	case "offset":
+		b.ScrollByOffset(s.X, s.Y)
+	case "bottom":
+		b.ScrollToBottom()
+	}
+}
+
+type ScrollUntilStable struct {
+	IterLimit         *uint16 `json:"iter_limit"`
+	PauseTime         *uint32 `json:"pause_time"`
+	SnapshotName      string  `json:"snapshot_name"`
+	SaveHtml          bool    `json:"save_html"`
+	SaveFullPageImage bool    `json:"save_full_page_image"`
+	ImageQuality      uint8   `json:"image_quality"`
+}
+
+func (s *ScrollUntilStable) Validate() error {
+
+	if s.PauseTime == nil {
+		pause := uint32(1000)
+		s.PauseTime = &pause
+	}
+
+	if s.IterLimit == nil {
+		iterLimit := uint16(50)
+		s.IterLimit = &iterLimit
+	}
+
+	if (s.SaveHtml || s.SaveFullPageImage) && s.SnapshotName == "" {
+		return errors.New("snapshot name is required when saving html or images")
+	}
+
+	if strings.Contains(s.SnapshotName, ".") {
+		return errors.New("snapshot_name must be folder not a file")
+	}
+
+	if s.SaveFullPageImage {
+		if s.ImageQuality == 0 {
+			return errors.New("image quality must be provided and greater than 0")
+		}
+	}
+
+	return nil
+}
+
+func (s *ScrollUntilStable) AppendTask(b *browser.Executor) {
+	b.ScrollUntilStable(
+		*s.IterLimit,
+		*s.PauseTime,
+		s.SnapshotName,
+		s.ImageQuality,
+		s.SaveFullPageImage,
+		s.SaveHtml)
+}
+
+type EvaluateJs struct {
+	Expression     string `json:"expression"`
+	AwaitPromise   bool   `json:"await_promise"`
+	Key            string `json:"key"`
+	SnapShotFolder string `json:"snapshot_name"`
+}
+
+func (e *EvaluateJs) Validate() error {
+	if e.Expression == "" {
+		return errors.New("expression is required")
+	}
+
+	if !regexp.MustCompile(`^[A-Za-z0-9_-]+$`).MatchString(e.Key) {
+		return errors.New("key must only contain letters, numbers, underscores and dashes")
+	}
+
+	if e.SnapShotFolder == "" {
+		return errors.New("snapshot_name is required")
+	}
+
+	if strings.Contains(e.SnapShotFolder, ".") {
+		return errors.New("snapshot_folder must be folder not a file")
+	}
+
+	return nil
+}
+
+func (e *EvaluateJs) AppendTask(b *browser.Executor) {
+	b.EvaluateJs(e.Expression, e.Key, e.SnapShotFolder, e.AwaitPromise)
+}
+
+type SelectOption struct {
+	ElementSelector
+	Value string `json:"value"`
+}
+
+func (s *SelectOption) Validate() error {
+	return s.validateSelector()
+}
+
+func (s *SelectOption) AppendTask(b *browser.Executor) {
+	b.SelectOption(s.Selector, s.Value, s.queryOption())
+}
+
+type Hover struct {
+	ElementSelector
+}
+
+func (h *Hover) Validate() error {
+	return h.validateSelector()
+}
+
+func (h *Hover) AppendTask(b *browser.Executor) {
+	b.Hover(h.Selector, h.queryOption())
+}
+
+func getNamedKeyMap() map[string]string {
+	return map[string]string{
+		"Enter":      kb.Enter,
+		"Tab":        kb.Tab,
+		"Escape":     kb.Escape,
+		"Backspace":  kb.Backspace,
+		"Delete":     kb.Delete,
+		"Space":      " ",
+		"ArrowUp":    kb.ArrowUp,
+		"ArrowDown":  kb.ArrowDown,
+		"ArrowLeft":  kb.ArrowLeft,
+		"ArrowRight": kb.ArrowRight,
+		"Home":       kb.Home,
+		"End":        kb.End,
+		"PageUp":     kb.PageUp,
+		"PageDown":   kb.PageDown,
+	}
+}
+
+func getModifierMap() map[string]input.Modifier {
+	return map[string]input.Modifier{
+		"alt":   input.ModifierAlt,
+		"ctrl":  input.ModifierCtrl,
+		"meta":  input.ModifierMeta,
+		"shift": input.ModifierShift,
+	}
+}
+
+type PressKey struct {
+	ElementSelector
+	Key       string   `json:"key"`
+	Modifiers []string `json:"modifiers"`
+	keys      string
+	modifiers []input.Modifier
+}
+
+func (p *PressKey) Validate() error {
+
+	if p.Selector != "" {
+		if err := p.validateSelector(); err != nil {
+			return err
+		}
+	}
+
+	if named, ok := getNamedKeyMap()[p.Key]; ok {
+		p.keys = named
+	} else if utf8.RuneCountInString(p.Key) == 1 {
+		p.keys = p.Key
+	} else {
+		return fmt.Errorf("key %s must be a single character or a named key", p.Key)
+	}
+
+	p.modifiers = nil
+	for _, m := range p.Modifiers {
+		modifier, ok := getModifierMap()[m]
+		if !ok {
+			return fmt.Errorf("modifier %s not supported", m)
+		}
+		p.modifiers = append(p.modifiers, modifier)
+	}
+
+	return nil
+}
+
+func (p *PressKey) AppendTask(b *browser.Executor) {
+	b.PressKey(p.Selector, p.keys, p.modifiers, p.queryOption())
+}
+
+type Check struct {
+	ElementSelector
+	Checked *bool `json:"checked"`
+}
+
+func (c *Check) Validate() error {
+	if c.Checked == nil {
+		checked := true
+		c.Checked = &checked
+	}
+
+	return c.validateSelector()
+}
+
+func (c *Check) AppendTask(b *browser.Executor) {
+	b.Check(c.Selector, *c.Checked, c.queryOption())
+}
+
+type SetFileInput struct {
+	ElementSelector
+	Files []string `json:"files"`
+}
+
+func (s *SetFileInput) Validate() error {
+	if len(s.Files) == 0 {
+		return errors.New("files are required")
+	}
+
+	// the browser only accepts absolute paths
+	for i, file := range s.Files {
+		pth, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+
+		info, err := os.Stat(pth)
+		if err != nil {
+			return fmt.Errorf("file %s cannot be read: %v", file, err)
+		}
+
+		if info.IsDir() {
+			return fmt.Errorf("file %s is a directory", file)
+		}
+
+		s.Files[i] = pth
+	}
+
+	return s.validateSelector()
+}
+
+func (s *SetFileInput) AppendTask(b *browser.Executor) {
+	b.SetFileInput(s.Selector, s.Files, s.queryOption())
+}
+
+func getPaperSizeMap() map[string][2]float64 {
+	return map[string][2]float64{
+		"letter":  {8.5, 11},
+		"legal":   {8.5, 14},
+		"tabloid": {11, 17},
+		"a3":      {11.69, 16.54},
+		"a4":      {8.27, 11.69},
+		"a5":      {5.83, 8.27},
+	}
+}
+
+type PdfMargins struct {
+	Top    float64 `json:"top"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+	Right  float64 `json:"right"`
+}
+
+type PrintPdf struct {
+	Name            string      `json:"name"`
+	SnapShotFolder  string      `json:"snapshot_name"`
+	Landscape       bool        `json:"landscape"`
+	PrintBackground bool        `json:"print_background"`
+	PaperSize       string      `json:"paper_size"`
+	Margins         *PdfMargins `json:"margins"`
+	PageRanges      string      `json:"page_ranges"`
+}
+
+func (p *PrintPdf) Validate() error {
+	if !strings.HasSuffix(p.Name, ".pdf") {
+		return errors.New("name must end with .pdf")
+	}
+
+	if filepath.Base(p.Name) != p.Name {
+		return errors.New("name must be a file name not a path")
+	}
+
+	if strings.Contains(p.SnapShotFolder, ".") {
+		return errors.New("snapshot_folder must be folder not a file")
+	}
+
+	if p.PaperSize != "" {
+		if _, ok := getPaperSizeMap()[p.PaperSize]; !ok {
+			return fmt.Errorf("paper size %s not supported", p.PaperSize)
+		}
+	}
+
+	if p.Margins != nil {
+		if p.Margins.Top < 0 || p.Margins.Bottom < 0 || p.Margins.Left < 0 || p.Margins.Right < 0 {
+			return errors.New("margins must not be negative")
+		}
+	}
+
+	if p.PageRanges != "" && !regexp.MustCompile(`^\d+(-\d*)?(,\s*\d+(-\d*)?)*$`).MatchString(p.PageRanges) {
+		return fmt.Errorf("page ranges %s must look like 1-5, 8, 11-13", p.PageRanges)
+	}
+
+	return nil
+}
+
+func (p *PrintPdf) AppendTask(b *browser.Executor) {
+	options := browser.PdfOptions{
+		Landscape:       p.Landscape,
+		PrintBackground: p.PrintBackground,
+		PageRanges:      p.PageRanges,
+	}
+
+	if size, ok := getPaperSizeMap()[p.PaperSize]; ok {
+		options.PaperWidth, options.PaperHeight = size[0], size[1]
+	}
+
+	if p.Margins != nil {
+		options.Margins = &[4]float64{p.Margins.Top, p.Margins.Bottom, p.Margins.Left, p.Margins.Right}
+	}
+
+	b.PrintPdf(p.Name, p.SnapShotFolder, options)
+}
+
+func getMediaFeatureMap() map[string][]string {
+	return map[string][]string{
+		"media":                  {"screen", "print"},
+		"prefers_color_scheme":   {"light", "dark", "no-preference"},
+		"prefers_reduced_motion": {"reduce", "no-preference"},
+		"forced_colors":          {"active", "none"},
+	}
+}
+
+type EmulateMedia struct {
+	Media         string `json:"media"`
+	ColorScheme   string `json:"prefers_color_scheme"`
+	ReducedMotion string `json:"prefers_reduced_motion"`
+	ForcedColors  string `json:"forced_colors"`
+}
+
+func (e *EmulateMedia) Validate() error {
+	featureMap := getMediaFeatureMap()
+
+	for feature, value := range map[string]string{
+		"media":                  e.Media,
+		"prefers_color_scheme":   e.ColorScheme,
+		"prefers_reduced_motion": e.ReducedMotion,
+		"forced_colors":          e.ForcedColors,
+	} {
+		if value != "" && !helper.Contains[string](featureMap[feature], value) {
+			return fmt.Errorf("%s %s not supported", feature, value)
+		}
+	}
+
+	return nil
+}
+
+func (e *EmulateMedia) AppendTask(b *browser.Executor) {
+	b.EmulateMedia(browser.MediaEmulation{
+		Media:         e.Media,
+		ColorScheme:   e.ColorScheme,
+		ReducedMotion: e.ReducedMotion,
+		ForcedColors:  e.ForcedColors,
+	})
+}
+
+func getVisionDeficiencyMap() map[string]emulation.SetEmulatedVisionDeficiencyType {
+	return map[string]emulation.SetEmulatedVisionDeficiencyType{
+		"none":           emulation.SetEmulatedVisionDeficiencyTypeNone,
+		"protanopia":     emulation.SetEmulatedVisionDeficiencyTypeProtanopia,
+		"deuteranopia":   emulation.SetEmulatedVisionDeficiencyTypeDeuteranopia,
+		"tritanopia":     emulation.SetEmulatedVisionDeficiencyTypeTritanopia,
+		"achromatopsia":  emulation.SetEmulatedVisionDeficiencyTypeAchromatopsia,
+		"blurred_vision": emulation.SetEmulatedVisionDeficiencyTypeBlurredVision,
+	}
+}
+
+type EmulateVisionDeficiency struct {
+	Deficiency string `json:"deficiency"`
+}
+
+func (e *EmulateVisionDeficiency) Validate() error {
+	if _, ok := getVisionDeficiencyMap()[e.Deficiency]; !ok {
+		return fmt.Errorf("vision deficiency %s not supported", e.Deficiency)
+	}
+
+	return nil
+}
+
+func (e *EmulateVisionDeficiency) AppendTask(b *browser.Executor) {
+	b.EmulateVisionDeficiency(getVisionDeficiencyMap()[e.Deficiency])
+}
+
+type ExtractText struct {
+	SnapShotFolder string `json:"snapshot_name"`
+	SourceFile     string `json:"source_file"`
+	MainContent    bool   `json:"main_content"`
+}
+
+func (e *ExtractText) Validate() error {
+	if e.SnapShotFolder == "" {
+		return errors.New("snapshot_name is required")
+	}
+
+	if strings.Contains(e.SnapShotFolder, ".") {
+		return errors.New("snapshot_folder must be folder not a file")
+	}
+
+	if strings.ContainsAny(e.SourceFile, `/\`) {
+		return errors.New("source_file must be the name of a file in the snapshot folder")
+	}
+
+	return nil
+}
+
+func (e *ExtractText) AppendTask(b *browser.Executor) {
+	b.ExtractText(e.SnapShotFolder, e.SourceFile, e.MainContent)
+}
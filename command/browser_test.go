@@ -0,0 +1,302 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWaitForValidate(t *testing.T) {
+
+	failTable := []WaitFor{
+		{
+			ElementSelector: ElementSelector{Selector: "body"},
+			Condition:       "hidden",
+		},
+		{
+			Condition: "visible",
+		},
+		{
+			Condition: "url_matches",
+		},
+		{
+			Condition:  "url_matches",
+			UrlPattern: "(unclosed",
+		},
+	}
+
+	passTable := []WaitFor{
+		{
+			ElementSelector: ElementSelector{Selector: "body"},
+			Condition:       "not_present",
+		},
+		{
+			Condition:  "url_matches",
+			UrlPattern: "^https://bench-ai\\.com/.*",
+		},
+	}
+
+	for _, w := range failTable {
+		if err := w.Validate(); err == nil {
+			t.Errorf("failed to detect invalid wait_for %v", w)
+		}
+	}
+
+	for _, w := range passTable {
+		if err := w.Validate(); err != nil {
+			t.Errorf("failed to detect valid wait_for: %v", err)
+		}
+
+		if *w.Timeout != 10 {
+			t.Error("timeout did not default to 10 seconds")
+		}
+	}
+}
+
+func TestScrollValidate(t *testing.T) {
+
+	failTable := []Scroll{
+		{Mode: "sideways"},
+		{Mode: "element"},
+		{Mode: "offset"},
+	}
+
+	passTable := []Scroll{
+		{Mode: "bottom"},
+		{Mode: "offset", Y: 500},
+		{Mode: "element", ElementSelector: ElementSelector{Selector: "footer", QueryType: "css"}},
+	}
+
+	for _, s := range failTable {
+		if err := s.Validate(); err == nil {
+			t.Errorf("failed to detect invalid scroll %v", s)
+		}
+	}
+
+	for _, s := range passTable {
+		if err := s.Validate(); err != nil {
+			t.Errorf("failed to detect valid scroll: %v", err)
+		}
+	}
+}
+
+func TestEvaluateJsValidate(t *testing.T) {
+
+	failTable := []EvaluateJs{
+		{Key: "title", SnapShotFolder: "s1"},
+		{Expression: "document.title", Key: "../title", SnapShotFolder: "s1"},
+		{Expression: "document.title", Key: "title"},
+	}
+
+	for _, e := range failTable {
+		if err := e.Validate(); err == nil {
+			t.Errorf("failed to detect invalid evaluate_js %v", e)
+		}
+	}
+
+	e := EvaluateJs{Expression: "document.title", Key: "page_title", SnapShotFolder: "s1"}
+
+	if err := e.Validate(); err != nil {
+		t.Errorf("failed to detect valid evaluate_js: %v", err)
+	}
+}
+
+func TestPressKeyValidate(t *testing.T) {
+
+	failTable := []PressKey{
+		{Key: "Return"},
+		{Key: "a", Modifiers: []string{"hyper"}},
+		{Key: ""},
+	}
+
+	for _, p := range failTable {
+		if err := p.Validate(); err == nil {
+			t.Errorf("failed to detect invalid press_key %v", p)
+		}
+	}
+
+	p := PressKey{Key: "Enter", Modifiers: []string{"ctrl", "shift"}}
+
+	if err := p.Validate(); err != nil {
+		t.Errorf("failed to detect valid press_key: %v", err)
+	}
+
+	if p.keys != "\r" || len(p.modifiers) != 2 {
+		t.Error("named key or modifiers were not resolved")
+	}
+
+	p = PressKey{Key: "a"}
+
+	if err := p.Validate(); err != nil || p.keys != "a" {
+		t.Error("single character key was not accepted")
+	}
+}
+
+func TestPrintPdfValidate(t *testing.T) {
+
+	failTable := []PrintPdf{
+		{Name: "page.png"},
+		{Name: "page.pdf", PaperSize: "b5"},
+		{Name: "page.pdf", Margins: &PdfMargins{Top: -1}},
+		{Name: "page.pdf", PageRanges: "first"},
+		{Name: "../page.pdf"},
+	}
+
+	for _, p := range failTable {
+		if err := p.Validate(); err == nil {
+			t.Errorf("failed to detect invalid print_pdf %v", p)
+		}
+	}
+
+	p := PrintPdf{Name: "page.pdf", SnapShotFolder: "s1", PaperSize: "a4", PageRanges: "1-5, 8, 11-13"}
+
+	if err := p.Validate(); err != nil {
+		t.Errorf("failed to detect valid print_pdf: %v", err)
+	}
+}
+
+func TestSaveHtmlValidate(t *testing.T) {
+
+	s := SaveHtml{SnapShotFolder: "s1"}
+
+	if err := s.Validate(); err != nil {
+		t.Errorf("failed to detect valid save_html: %v", err)
+	}
+
+	if s.Selector != "html" || s.QueryType != "css" || s.Name != "page.html" {
+		t.Error("save_html did not default to the whole document")
+	}
+
+	failTable := []SaveHtml{
+		{SnapShotFolder: "s1", Name: "body.txt"},
+		{SnapShotFolder: "s1", Name: "../../x.html"},
+		{SnapShotFolder: "s1.html"},
+		{SnapShotFolder: "s1", ElementSelector: ElementSelector{Selector: "main", QueryType: "class"}},
+	}
+
+	for _, f := range failTable {
+		if err := f.Validate(); err == nil {
+			t.Errorf("failed to detect invalid save_html %v", f)
+		}
+	}
+}
+
+func TestEmulateMediaValidate(t *testing.T) {
+
+	passTable := []EmulateMedia{
+		{},
+		{Media: "print"},
+		{ColorScheme: "dark", ReducedMotion: "reduce", ForcedColors: "active"},
+	}
+
+	for _, p := range passTable {
+		if err := p.Validate(); err != nil {
+			t.Errorf("failed to detect valid emulate_media %v: %v", p, err)
+		}
+	}
+
+	failTable := []EmulateMedia{
+		{Media: "tv"},
+		{ColorScheme: "blue"},
+		{ReducedMotion: "none"},
+		{ForcedColors: "dark"},
+	}
+
+	for _, f := range failTable {
+		if err := f.Validate(); err == nil {
+			t.Errorf("failed to detect invalid emulate_media %v", f)
+		}
+	}
+}
+
+func TestEmulateVisionDeficiencyValidate(t *testing.T) {
+
+	for _, deficiency := range []string{"none", "protanopia", "blurred_vision"} {
+		e := EmulateVisionDeficiency{Deficiency: deficiency}
+		if err := e.Validate(); err != nil {
+			t.Errorf("failed to detect valid emulate_vision_deficiency %v: %v", e, err)
+		}
+	}
+
+	for _, deficiency := range []string{"", "blurredVision", "colorblind"} {
+		e := EmulateVisionDeficiency{Deficiency: deficiency}
+		if err := e.Validate(); err == nil {
+			t.Errorf("failed to detect invalid emulate_vision_deficiency %v", e)
+		}
+	}
+}
+
+func TestStorageStateValidate(t *testing.T) {
+
+	s := SaveStorageState{}
+	if err := s.Validate(); err != nil || s.Name != "storage_state.json" {
+		t.Errorf("save_storage_state did not default its name: %v", err)
+	}
+
+	l := LoadStorageState{SessionId: "login"}
+	if err := l.Validate(); err != nil || l.Name != "storage_state.json" {
+		t.Errorf("load_storage_state did not default its name: %v", err)
+	}
+
+	saveFailTable := []SaveStorageState{
+		{Name: "state.txt"},
+		{Name: "../state.json"},
+	}
+
+	for _, f := range saveFailTable {
+		if err := f.Validate(); err == nil {
+			t.Errorf("failed to detect invalid save_storage_state %v", f)
+		}
+	}
+
+	loadFailTable := []LoadStorageState{
+		{SessionId: ".."},
+		{SessionId: "other/session"},
+		{Name: "dir/state.json"},
+	}
+
+	for _, f := range loadFailTable {
+		if err := f.Validate(); err == nil {
+			t.Errorf("failed to detect invalid load_storage_state %v", f)
+		}
+	}
+}
+
+func TestSetFileInputValidate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "resume.pdf")
+	if err := os.WriteFile(file, []byte("%PDF"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relative, err := filepath.Rel(wd, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := SetFileInput{ElementSelector: ElementSelector{Selector: "#upload"}, Files: []string{relative}}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("failed to validate set_file_input: %v", err)
+	}
+
+	if s.Files[0] != file {
+		t.Errorf("expected the file path to be made absolute, found %s", s.Files[0])
+	}
+
+	failTable := []SetFileInput{
+		{ElementSelector: ElementSelector{Selector: "#upload"}},
+		{ElementSelector: ElementSelector{Selector: "#upload"}, Files: []string{filepath.Dir(file)}},
+		{ElementSelector: ElementSelector{Selector: "#upload"}, Files: []string{file + ".missing"}},
+		{Files: []string{file}},
+	}
+
+	for _, f := range failTable {
+		if err := f.Validate(); err == nil {
+			t.Errorf("failed to detect invalid set_file_input %v", f)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package command
+
+import (
+	"agent/browser"
+	"errors"
+	"fmt"
+	"github.com/chromedp/chromedp"
+	"regexp"
+)
+
+// ElementSelector
+/*
+The selector shared by every command that targets an element. query_type decides how chromedp resolves the selector
+*/
+type ElementSelector struct {
+	Selector  string `json:"selector"`
+	QueryType string `json:"query_type"`
+}
+
+func getQueryTypeMap() map[string]chromedp.QueryOption {
+	return map[string]chromedp.QueryOption{
+		"xpath":   chromedp.BySearch,
+		"css":     chromedp.ByQuery,
+		"id":      chromedp.ByID,
+		"js_path": chromedp.ByJSPath,
+		"search":  chromedp.BySearch,
+	}
+}
+
+// validateSelector
+/*
+checks that a selector was provided and that its query type is supported, defaults the query type to search
+*/
+func (e *ElementSelector) validateSelector() error {
+	if e.Selector == "" {
+		return errors.New("selector is required")
+	}
+
+	if e.QueryType == "" {
+		e.QueryType = "search"
+	}
+
+	if _, ok := getQueryTypeMap()[e.QueryType]; !ok {
+		return fmt.Errorf("query type %s not supported", e.QueryType)
+	}
+
+	return nil
+}
+
+// queryOption
+/*
+returns the chromedp query option matching the query type, must be called after validation
+*/
+func (e *ElementSelector) queryOption() chromedp.QueryOption {
+	return getQueryTypeMap()[e.QueryType]
+}
+
+// queryAllOption
+/*
+the same as queryOption except css selectors match every element rather than only the first
+*/
+func (e *ElementSelector) queryAllOption() chromedp.QueryOption {
+	if e.QueryType == "css" {
+		return chromedp.ByQueryAll
+	}
+
+	return e.queryOption()
+}
+
+// TabSelector
+/*
+The selector shared by the commands that target a tab. A tab is picked by one of its index, name, a regex matching
+its url or text in its title
+*/
+type TabSelector struct {
+	Index      *int   `json:"index"`
+	Name       string `json:"name"`
+	UrlPattern string `json:"url_pattern"`
+	Title      string `json:"title"`
+	urlRegex   *regexp.Regexp
+}
+
+// validateTabSelector
+/*
+checks that at most one way of picking the tab was provided, or exactly one when required
+*/
+func (t *TabSelector) validateTabSelector(required bool) error {
+	count := 0
+	for _, set := range []bool{t.Index != nil, t.Name != "", t.UrlPattern != "", t.Title != ""} {
+		if set {
+			count++
+		}
+	}
+
+	if count > 1 {
+		return errors.New("only one of index, name, url_pattern or title can be provided")
+	}
+
+	if required && count == 0 {
+		return errors.New("one of index, name, url_pattern or title is required")
+	}
+
+	if t.Index != nil && *t.Index < 0 {
+		return errors.New("index cannot be negative")
+	}
+
+	if t.UrlPattern != "" {
+		var err error
+		if t.urlRegex, err = regexp.Compile(t.UrlPattern); err != nil {
+			return fmt.Errorf("url_pattern is not a valid regex: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// tabSelector
+/*
+converts the selector for the executor, must be called after validation
+*/
+func (t *TabSelector) tabSelector() browser.TabSelector {
+	return browser.TabSelector{
+		Index:      t.Index,
+		Name:       t.Name,
+		UrlPattern: t.urlRegex,
+		Title:      t.Title,
+	}
+}
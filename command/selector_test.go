@@ -0,0 +1,89 @@
+package command
+
+import (
+	"testing"
+)
+
+func TestValidateSelector(t *testing.T) {
+
+	failTable := []ElementSelector{
+		{
+			Selector:  "",
+			QueryType: "css",
+		},
+		{
+			Selector:  "body",
+			QueryType: "class",
+		},
+	}
+
+	passTable := []ElementSelector{
+		{Selector: "//body", QueryType: "xpath"},
+		{Selector: "body > div", QueryType: "css"},
+		{Selector: "main", QueryType: "id"},
+		{Selector: "document.body", QueryType: "js_path"},
+		{Selector: "body", QueryType: "search"},
+	}
+
+	for _, s := range failTable {
+		if err := s.validateSelector(); err == nil {
+			t.Errorf("failed to detect invalid selector %v", s)
+		}
+	}
+
+	for _, s := range passTable {
+		if err := s.validateSelector(); err != nil {
+			t.Errorf("failed to detect valid selector %v", s)
+		}
+
+		if s.queryOption() == nil {
+			t.Errorf("no query option found for query type %s", s.QueryType)
+		}
+	}
+
+	empty := ElementSelector{Selector: "body"}
+
+	if err := empty.validateSelector(); err != nil || empty.QueryType != "search" {
+		t.Error("blank query type did not default to search")
+	}
+}
+
+func TestValidateTabSelector(t *testing.T) {
+	index := 1
+	negative := -1
+
+	passTable := []TabSelector{
+		{Index: &index},
+		{Name: "docs"},
+		{UrlPattern: "^https://bench-ai.com"},
+		{Title: "Bench"},
+	}
+
+	for i := range passTable {
+		if err := passTable[i].validateTabSelector(true); err != nil {
+			t.Errorf("failed to detect valid tab selector %v: %v", passTable[i], err)
+		}
+	}
+
+	if selector := passTable[2].tabSelector(); selector.UrlPattern == nil {
+		t.Error("url pattern was not compiled")
+	}
+
+	empty := TabSelector{}
+	if err := empty.validateTabSelector(false); err != nil {
+		t.Errorf("an empty tab selector should pick the active tab: %v", err)
+	}
+
+	failTable := []TabSelector{
+		{},
+		{Index: &index, Name: "docs"},
+		{Index: &negative},
+		{UrlPattern: "("},
+	}
+
+	for _, s := range failTable {
+		if err := s.validateTabSelector(true); err == nil {
+			t.Errorf("failed to detect invalid tab selector %v", s)
+		}
+	}
+}
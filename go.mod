@@ -5,6 +5,7 @@ go 1.22
 require (
 	github.com/chromedp/cdproto v0.0.0-20240328024531-fe04f09ede24
 	github.com/chromedp/chromedp v0.9.5
+	golang.org/x/net v0.24.0
 )
 
 require (
@@ -24,13 +24,28 @@ type Workflow struct {
 	WorkflowType string `json:"workflow_type"`
 }
 type Settings struct {
-	Timeout     *int16                   `json:"timeout"`
-	Headless    bool                     `json:"headless"`
-	MaxToken    *int                     `json:"max_tokens"`
-	Credentials []Credentials            `json:"credentials"`
-	Workflow    Workflow                 `json:"workflow"`
-	LLMSettings []map[string]interface{} `json:"llm_settings"`
-	TryLimit    int16                    `json:"try_limit"`
+	Timeout      *int16                   `json:"timeout"`
+	Headless     bool                     `json:"headless"`
+	MaxToken     *int                     `json:"max_tokens"`
+	Credentials  []Credentials            `json:"credentials"`
+	Workflow     Workflow                 `json:"workflow"`
+	LLMSettings  []map[string]interface{} `json:"llm_settings"`
+	TryLimit     int16                    `json:"try_limit"`
+	RecordEvents bool                     `json:"record_events"`
+	RecordHar    bool                     `json:"record_har"`
+	HarBodies    bool                     `json:"har_include_bodies"`
+	NetworkRules []browser.NetworkRule    `json:"network_rules"`
+	Emulation    *browser.Emulation       `json:"emulation"`
+	DialogPolicy *browser.DialogPolicy    `json:"dialog_policy"`
+
+	ExecPath                string                 `json:"exec_path"`
+	UserDataDir             string                 `json:"user_data_dir"`
+	ProxyServer             string                 `json:"proxy_server"`
+	IgnoreCertificateErrors bool                   `json:"ignore_certificate_errors"`
+	WindowSize              *browser.WindowSize    `json:"window_size"`
+	ExtraFlags              map[string]interface{} `json:"extra_flags"`
+	RemoteDebuggingUrl      string                 `json:"remote_debugging_url"`
+	SaveDownloads           bool                   `json:"save_downloads"`
 }
 
 type Command struct {
@@ -48,7 +63,25 @@ type Operation struct {
 
 func runBrowserCommands(settings Settings, commandList []Command, sessionPath string) {
 	var browserBuilder browser.Executor
-	browserBuilder.Init(settings.Headless, settings.Timeout, sessionPath)
+	browserBuilder.Init(browser.Options{
+		Headless:     settings.Headless,
+		Timeout:      settings.Timeout,
+		RecordEvents: settings.RecordEvents,
+		RecordHar:    settings.RecordHar,
+		HarBodies:    settings.HarBodies,
+		NetworkRules: settings.NetworkRules,
+		Emulation:    settings.Emulation,
+		DialogPolicy: settings.DialogPolicy,
+
+		ExecPath:                settings.ExecPath,
+		UserDataDir:             settings.UserDataDir,
+		ProxyServer:             settings.ProxyServer,
+		IgnoreCertificateErrors: settings.IgnoreCertificateErrors,
+		WindowSize:              settings.WindowSize,
+		ExtraFlags:              settings.ExtraFlags,
+		RemoteDebuggingUrl:      settings.RemoteDebuggingUrl,
+		SaveDownloads:           settings.SaveDownloads,
+	}, sessionPath)
 
 	for _, com := range commandList {
 		addOperation(com, &browserBuilder)
@@ -419,18 +452,68 @@ func addOperation(com Command, builder *browser.Executor) {
 		browserParams = &command.FullPageScreenShot{}
 	case "element_screenshot":
 		browserParams = &command.ElementScreenshot{}
+	case "print_pdf":
+		browserParams = &command.PrintPdf{}
 	case "collect_nodes":
 		browserParams = &command.CollectNodes{}
+	case "collect_accessibility_tree":
+		browserParams = &command.CollectAccessibilityTree{}
+	case "audit_accessibility":
+		browserParams = &command.AuditAccessibility{}
 	case "click":
 		browserParams = &command.Click{}
+	case "type_text":
+		browserParams = &command.TypeText{}
+	case "select_option":
+		browserParams = &command.SelectOption{}
+	case "hover":
+		browserParams = &command.Hover{}
+	case "press_key":
+		browserParams = &command.PressKey{}
+	case "check":
+		browserParams = &command.Check{}
 	case "save_html":
 		browserParams = &command.SaveHtml{}
+	case "save_mhtml":
+		browserParams = &command.SaveMhtml{}
+	case "extract_text":
+		browserParams = &command.ExtractText{}
 	case "sleep":
 		browserParams = &command.Sleep{}
+	case "wait_for":
+		browserParams = &command.WaitFor{}
+	case "scroll":
+		browserParams = &command.Scroll{}
+	case "scroll_until_stable":
+		browserParams = &command.ScrollUntilStable{}
 	case "iterate_html":
 		browserParams = &command.IterateHtml{}
 	case "acquire_location":
 		browserParams = &command.AcquireLocation{}
+	case "evaluate_js":
+		browserParams = &command.EvaluateJs{}
+	case "emulate_media":
+		browserParams = &command.EmulateMedia{}
+	case "emulate_vision_deficiency":
+		browserParams = &command.EmulateVisionDeficiency{}
+	case "save_storage_state":
+		browserParams = &command.SaveStorageState{}
+	case "load_storage_state":
+		browserParams = &command.LoadStorageState{}
+	case "new_tab":
+		browserParams = &command.NewTab{}
+	case "switch_tab":
+		browserParams = &command.SwitchTab{}
+	case "list_tabs":
+		browserParams = &command.ListTabs{}
+	case "close_tab":
+		browserParams = &command.CloseTab{}
+	case "enter_frame":
+		browserParams = &command.EnterFrame{}
+	case "exit_frame":
+		browserParams = &command.ExitFrame{}
+	case "set_file_input":
+		browserParams = &command.SetFileInput{}
 	default:
 		log.Fatalf("%s is not a supported browser command \n", com.CommandName)
 	}